@@ -15,55 +15,372 @@
 package errors
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
 )
 
 // DefaultHTTPCode is used when the error Code cannot be used as an HTTP code.
 var DefaultHTTPCode = http.StatusUnprocessableEntity
 
+// CanceledHTTPCode is used to serve errors caused by a canceled context, e.g. when the client went away.
+//
+// It defaults to 499 (client closed request).
+var CanceledHTTPCode = 499
+
+// DeadlineExceededHTTPCode is used to serve errors caused by a context deadline
+var DeadlineExceededHTTPCode = http.StatusGatewayTimeout
+
+// CodeFieldName is the name of the JSON field holding the code of an error
+var CodeFieldName = "code"
+
+// MessageFieldName is the name of the JSON field holding the message of an error, e.g. "detail" or "error"
+var MessageFieldName = "message"
+
+// IncludeRetryable adds a "retryable" field to serialized errors, telling clients if the request may be retried
+var IncludeRetryable bool
+
+// CodeAsString renders error codes as JSON strings instead of numbers when serializing errors
+var CodeAsString bool
+
+// SkipContentTypeHeader makes ServeError leave the Content-Type header alone, e.g. when a middleware manages it
+var SkipContentTypeHeader bool
+
+// AppendNewline makes ServeError terminate the JSON body of the response with a newline
+var AppendNewline bool
+
+// IncludeRequestInfo makes ServeError add the method and path of the request to the JSON body of the response.
+//
+// The query string is never included.
+var IncludeRequestInfo bool
+
+// ErrorTransformer replaces an error before ServeError writes it, e.g. to translate messages for the whole API.
+//
+// Returning nil keeps the original error. The ErrorLogger still receives the original error.
+var ErrorTransformer func(err error) error
+
+// ErrorLogger is called by ServeError with the request and the error about to be served, when set.
+//
+// This is the place to log the full details of an error, such as its cause or stack trace, which are not sent to clients.
+var ErrorLogger func(r *http.Request, err error)
+
+// CaptureStack makes New, the other constructors of API errors and Internal capture the stack of their caller
+// for errors with a 5xx status.
+//
+// The stack is never serialized. It may be retrieved with StackTrace(), e.g. from the ErrorLogger.
+var CaptureStack bool
+
+// TraceIDFromRequest extracts a correlation or trace ID from the request, when set.
+//
+// ServeError adds a non-empty trace ID to the JSON body of the response as "traceId".
+var TraceIDFromRequest func(r *http.Request) string
+
+// AllowJSONP makes ServeError wrap JSON bodies in the JavaScript function named by the "callback" query parameter,
+// served as "application/javascript" with "X-Content-Type-Options: nosniff", regardless of SkipContentTypeHeader.
+// Callbacks which are not plain JavaScript identifiers are ignored.
+var AllowJSONP bool
+
+// MaxMessageLength truncates the serialized message of errors beyond this number of characters, with an ellipsis.
+//
+// When 0, messages are not truncated.
+var MaxMessageLength int
+
+// TruncateErrorStrings applies MaxMessageLength to the Error() strings of errors as well, not only to their JSON
+var TruncateErrorStrings bool
+
+// PanicError is served by RecoverAndServe in place of the recovered panic value, to avoid leaking internals.
+var PanicError Error = New(http.StatusInternalServerError, "internal server error")
+
+// DocURLForCode resolves the URL of the documentation for an error code, when set.
+//
+// ServeError advertises a non-empty URL in a Link header with rel="help".
+var DocURLForCode func(code int32) string
+
+// SanitizeMessages escapes control characters (e.g. newlines or ANSI escape sequences) in error messages.
+//
+// This protects logs from injection through user-supplied values, such as the value reported by InvalidType.
+var SanitizeMessages bool
+
 // Error represents a error interface all swagger framework errors implement
 type Error interface {
 	error
 	Code() int32
 }
 
+// HTTPHeaderError is an Error which carries extra headers to set on the response.
+//
+// ServeError copies these headers onto the response before writing the status code.
+type HTTPHeaderError interface {
+	Error
+	Headers() http.Header
+}
+
+// FieldError is an Error about a named field, telling where this field was found, e.g. "query" or "body".
+//
+// It is implemented by validation and parse errors.
+type FieldError interface {
+	Error
+	FieldName() string
+	Location() string
+}
+
 type apiError struct {
 	code    int32
 	message string
+	// status is an explicit HTTP status, used instead of the code when set
+	status  int
+	stack   []uintptr
+	headers http.Header
+}
+
+// jsonMessage returns the message of an error as serialized in JSON
+func jsonMessage(msg string) string {
+	return truncateMessage(sanitizeMessage(msg))
+}
+
+// errorMessage returns the message of an error as returned by Error()
+func errorMessage(msg string) string {
+	msg = sanitizeMessage(msg)
+	if TruncateErrorStrings {
+		msg = truncateMessage(msg)
+	}
+	return msg
+}
+
+// truncateMessage shortens msg to MaxMessageLength characters, when set
+func truncateMessage(msg string) string {
+	if MaxMessageLength <= 0 || len(msg) <= MaxMessageLength {
+		return msg
+	}
+	runes := []rune(msg)
+	if len(runes) <= MaxMessageLength {
+		return msg
+	}
+	return string(runes[:MaxMessageLength]) + "..."
+}
+
+// sanitizeMessage escapes control characters in msg when SanitizeMessages is enabled
+func sanitizeMessage(msg string) string {
+	if !SanitizeMessages || strings.IndexFunc(msg, unicode.IsControl) < 0 {
+		return msg
+	}
+	var b strings.Builder
+	for _, r := range msg {
+		if unicode.IsControl(r) {
+			q := strconv.QuoteRune(r)
+			b.WriteString(q[1 : len(q)-1])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
 }
 
 func (a *apiError) Error() string {
-	return a.message
+	return errorMessage(a.message)
 }
 
 func (a *apiError) Code() int32 {
 	return a.code
 }
 
+// Headers returns the extra headers to set on the response, if any
+func (a *apiError) Headers() http.Header {
+	return a.headers
+}
+
+// StackTrace returns the program counters of the stack captured when this error was created, if any.
+//
+// See CaptureStack.
+func (a *apiError) StackTrace() []uintptr {
+	return a.stack
+}
+
+// HTTPStatus returns the HTTP status this error is served with
+func (a *apiError) HTTPStatus() int {
+	if a.status > 0 {
+		return a.status
+	}
+	return asHTTPCode(int(a.code))
+}
+
 // MarshalJSON implements the JSON encoding interface
 func (a apiError) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"code":    a.code,
-		"message": a.message,
-	})
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(a.code),
+		MessageFieldName: jsonMessage(a.message),
+	}
+	if IncludeRetryable {
+		m["retryable"] = a.Retryable()
+	}
+	return json.Marshal(m)
+}
+
+// Retryable tells if the request failing with this error may be retried, i.e. for 5xx and 429 statuses
+func (a *apiError) Retryable() bool {
+	return isRetryable(a.HTTPStatus())
 }
 
-// New creates a new API error with a code and a message
+// New creates a new API error with a code and a message.
+//
+// A code lower than or equal to 0 is replaced by DefaultHTTPCode.
 func New(code int32, message string, args ...interface{}) Error {
-	if len(args) > 0 {
-		return &apiError{
-			code:    code,
-			message: fmt.Sprintf(message, args...),
+	return newError(0, code, message, args...)
+}
+
+// NewWithStatus creates a new API error with a code and a message, served with an explicit HTTP status.
+//
+// This decouples the application code reported in the body from the HTTP status.
+func NewWithStatus(code int32, httpStatus int, message string, args ...interface{}) Error {
+	return newAPIError(0, code, httpStatus, message, args...)
+}
+
+// As400 returns a copy of err served with a 400 status, e.g. for API styles which report all validation errors as bad requests.
+//
+// The code of the error is unchanged. The children of a composite are restamped as well.
+// Errors other than API errors, validations and composites are returned unchanged.
+func As400(err error) error {
+	return withHTTPStatus(err, http.StatusBadRequest)
+}
+
+// As422 returns a copy of err served with a 422 status, like As400
+func As422(err error) error {
+	return withHTTPStatus(err, http.StatusUnprocessableEntity)
+}
+
+func withHTTPStatus(err error, status int) error {
+	switch e := err.(type) {
+	case *apiError:
+		if e == nil {
+			return err
+		}
+		c := *e
+		c.status = status
+		return &c
+	case *Validation:
+		if e == nil {
+			return err
+		}
+		c := *e
+		c.status = status
+		return &c
+	case *CompositeError:
+		if e == nil {
+			return err
 		}
+		c := *e
+		c.status = status
+		c.Errors = make([]error, 0, len(e.Errors))
+		for _, child := range e.Errors {
+			c.Errors = append(c.Errors, withHTTPStatus(child, status))
+		}
+		return &c
+	default:
+		return err
+	}
+}
+
+// newError creates an API error like New, falling back to DefaultHTTPCode for invalid codes.
+//
+// See newAPIError for skip.
+func newError(skip int, code int32, message string, args ...interface{}) *apiError {
+	if code <= 0 {
+		code = int32(DefaultHTTPCode)
 	}
-	return &apiError{
+	return newAPIError(skip+1, code, 0, message, args...)
+}
+
+// newAPIError creates an API error, capturing the stack for 5xx errors when CaptureStack is enabled.
+//
+// skip is the number of calls between the public constructor and newAPIError, e.g. 0 when called by NewWithStatus,
+// so that the captured stack starts with the caller of the constructor.
+func newAPIError(skip int, code int32, status int, message string, args ...interface{}) *apiError {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	e := &apiError{
 		code:    code,
+		status:  status,
 		message: message,
 	}
+	if CaptureStack && e.HTTPStatus() >= http.StatusInternalServerError {
+		e.stack = callers(skip + 1)
+	}
+	return e
+}
+
+const maxStackDepth = 32
+
+// callers captures the stack of the caller of a public constructor,
+// skip being the number of calls between the constructor and callers
+func callers(skip int) []uintptr {
+	var pcs [maxStackDepth]uintptr
+	// skip runtime.Callers, callers and the constructor itself
+	n := runtime.Callers(skip+3, pcs[:])
+	return pcs[:n]
+}
+
+// Equal tells if two errors are equivalent API errors, i.e. errors with the same code.
+//
+// Validation errors must also agree on their Name and In. Messages and values are ignored.
+func Equal(a, b error) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	ea, ok := a.(Error)
+	if !ok {
+		return false
+	}
+	eb, ok := b.(Error)
+	if !ok {
+		return false
+	}
+	if ea.Code() != eb.Code() {
+		return false
+	}
+	va, isValidationA := a.(*Validation)
+	vb, isValidationB := b.(*Validation)
+	if isValidationA != isValidationB {
+		return false
+	}
+	if isValidationA {
+		return va.Name == vb.Name && va.In == vb.In
+	}
+	return true
+}
+
+// HasCode tells if an error, or any error it wraps or groups, is an Error with the given code
+func HasCode(err error, code int32) bool {
+	if err == nil {
+		return false
+	}
+	if value := reflect.ValueOf(err); value.Kind() == reflect.Ptr && value.IsNil() {
+		return false
+	}
+	if e, ok := err.(Error); ok && e.Code() == code {
+		return true
+	}
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, child := range u.Unwrap() {
+			if HasCode(child, code) {
+				return true
+			}
+		}
+	case interface{ Unwrap() error }:
+		return HasCode(u.Unwrap(), code)
+	}
+	return false
 }
 
 // NotFound creates a new not found error
@@ -71,23 +388,116 @@ func NotFound(message string, args ...interface{}) Error {
 	if message == "" {
 		message = "Not found"
 	}
-	return New(http.StatusNotFound, fmt.Sprintf(message, args...))
+	return newError(0, http.StatusNotFound, fmt.Sprintf(message, args...))
+}
+
+// NotAcceptable creates a new not acceptable error
+func NotAcceptable(message string, args ...interface{}) Error {
+	if message == "" {
+		message = "Not acceptable"
+	}
+	return newError(0, http.StatusNotAcceptable, fmt.Sprintf(message, args...))
+}
+
+// NotModified creates a new not modified error, for conditional requests. It is served without a body.
+func NotModified() Error {
+	return newError(0, http.StatusNotModified, "Not modified")
+}
+
+// RequestTimeout creates a new request timeout error, e.g. when reading the request body times out
+func RequestTimeout(message string, args ...interface{}) Error {
+	if message == "" {
+		message = "Request timeout"
+	}
+	return newError(0, http.StatusRequestTimeout, fmt.Sprintf(message, args...))
+}
+
+// Locked creates a new locked error, e.g. for a resource locked by another client
+func Locked(message string, args ...interface{}) Error {
+	if message == "" {
+		message = "Locked"
+	}
+	return newError(0, http.StatusLocked, fmt.Sprintf(message, args...))
+}
+
+// UpgradeRequired creates a new upgrade required error, advertising the protocol in the Upgrade header
+func UpgradeRequired(protocol string) Error {
+	e := newAPIError(0, http.StatusUpgradeRequired, 0, "upgrade to %s required", protocol)
+	e.headers = http.Header{
+		"Upgrade":    []string{protocol},
+		"Connection": []string{"Upgrade"},
+	}
+	return e
+}
+
+// UnavailableForLegalReasons creates a new error for a resource which is unavailable for legal reasons
+func UnavailableForLegalReasons(message string, args ...interface{}) Error {
+	if message == "" {
+		message = "Unavailable for legal reasons"
+	}
+	return newError(0, http.StatusUnavailableForLegalReasons, fmt.Sprintf(message, args...))
+}
+
+// UnavailableForLegalReasonsBlockedBy creates a new error for a resource which is unavailable for legal reasons,
+// identifying the entity implementing the block with a Link header, with rel="blocked-by"
+func UnavailableForLegalReasonsBlockedBy(blockedBy, message string, args ...interface{}) Error {
+	e := UnavailableForLegalReasons(message, args...).(*apiError)
+	e.headers = http.Header{
+		"Link": []string{"<" + blockedBy + `>; rel="blocked-by"`},
+	}
+	return e
 }
 
 // NotImplemented creates a new not implemented error
 func NotImplemented(message string) Error {
-	return New(http.StatusNotImplemented, message)
+	return newError(0, http.StatusNotImplemented, message)
+}
+
+// FromHTTPStatus creates the error matching an HTTP status, e.g. to relay an upstream error
+func FromHTTPStatus(status int, message string, args ...interface{}) Error {
+	if len(args) > 0 {
+		message = fmt.Sprintf(message, args...)
+	}
+	// the formatted message is passed as an argument, so that it is not formatted again,
+	// while an empty message still gets the default message of the constructor
+	format, literal := message, []interface{}(nil)
+	if message != "" {
+		format, literal = "%s", []interface{}{message}
+	}
+
+	switch status {
+	case http.StatusNotFound:
+		return NotFound(format, literal...)
+	case http.StatusNotAcceptable:
+		return NotAcceptable(format, literal...)
+	case http.StatusRequestTimeout:
+		return RequestTimeout(format, literal...)
+	case http.StatusLocked:
+		return Locked(format, literal...)
+	case http.StatusUnavailableForLegalReasons:
+		return UnavailableForLegalReasons(format, literal...)
+	case http.StatusNotImplemented:
+		return newError(0, http.StatusNotImplemented, message)
+	case http.StatusUnauthorized:
+		if message == "" {
+			message = "Unauthenticated"
+		}
+		return newError(0, http.StatusUnauthorized, message)
+	default:
+		return newError(0, int32(status), message)
+	}
 }
 
 // MethodNotAllowedError represents an error for when the path matches but the method doesn't
 type MethodNotAllowedError struct {
-	code    int32
+	code int32
+	// Allowed methods, in the order of preference given to MethodNotAllowed
 	Allowed []string
 	message string
 }
 
 func (m *MethodNotAllowedError) Error() string {
-	return m.message
+	return errorMessage(m.message)
 }
 
 // Code the error code
@@ -95,24 +505,56 @@ func (m *MethodNotAllowedError) Code() int32 {
 	return m.code
 }
 
+// Headers returns the Allow header listing the allowed methods, in order
+func (m *MethodNotAllowedError) Headers() http.Header {
+	return http.Header{"Allow": []string{strings.Join(m.Allowed, ",")}}
+}
+
 // MarshalJSON implements the JSON encoding interface
 func (m MethodNotAllowedError) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"code":    m.code,
-		"message": m.message,
-		"allowed": m.Allowed,
-	})
+	fields := map[string]interface{}{
+		CodeFieldName:    jsonCode(m.code),
+		MessageFieldName: jsonMessage(m.message),
+		"allowed":        m.Allowed,
+	}
+	if IncludeRetryable {
+		fields["retryable"] = retryable(&m)
+	}
+	return json.Marshal(fields)
 }
 
 func errorAsJSON(err Error) []byte {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(err.Code()),
+		MessageFieldName: truncateMessage(err.Error()),
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(err)
+	}
 	//nolint:errchkjson
-	b, _ := json.Marshal(struct {
-		Code    int32  `json:"code"`
-		Message string `json:"message"`
-	}{err.Code(), err.Error()})
+	b, _ := json.Marshal(m)
 	return b
 }
 
+// retryable tells if the request failing with err may be retried, asking the error itself when it knows
+func retryable(err Error) bool {
+	if re, ok := err.(interface{ Retryable() bool }); ok {
+		return re.Retryable()
+	}
+	return isRetryable(httpStatus(err))
+}
+
+func isRetryable(status int) bool {
+	return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+}
+
+func jsonCode(code int32) interface{} {
+	if CodeAsString {
+		return strconv.FormatInt(int64(code), 10)
+	}
+	return code
+}
+
 func flattenComposite(errs *CompositeError) *CompositeError {
 	var res []error
 	for _, er := range errs.Errors {
@@ -133,55 +575,457 @@ func flattenComposite(errs *CompositeError) *CompositeError {
 	return CompositeValidationError(res...)
 }
 
-// MethodNotAllowed creates a new method not allowed error
+// MethodNotAllowed creates a new method not allowed error.
+//
+// The allowed methods are reported in the given order, e.g. by order of preference.
 func MethodNotAllowed(requested string, allow []string) Error {
 	msg := fmt.Sprintf("method %s is not allowed, but [%s] are", requested, strings.Join(allow, ","))
+	var allowed []string
+	if allow != nil {
+		allowed = make([]string, len(allow))
+		copy(allowed, allow)
+	}
 	return &MethodNotAllowedError{
 		code:    http.StatusMethodNotAllowed,
-		Allowed: allow,
+		Allowed: allowed,
 		message: msg,
 	}
 }
 
+// InternalError represents a server error which hides its cause from clients.
+//
+// Only the client message is served: the cause is reachable with errors.Unwrap, e.g. from the ErrorLogger.
+type InternalError struct {
+	code    int32
+	Cause   error
+	message string
+	stack   []uintptr
+}
+
+func (i *InternalError) Error() string {
+	return errorMessage(i.message)
+}
+
+// Code the error code
+func (i *InternalError) Code() int32 {
+	return i.code
+}
+
+// Unwrap returns the cause of this error
+func (i *InternalError) Unwrap() error {
+	return i.Cause
+}
+
+// StackTrace returns the program counters of the stack captured when this error was created, if any.
+//
+// See CaptureStack.
+func (i *InternalError) StackTrace() []uintptr {
+	return i.stack
+}
+
+// MarshalJSON implements the JSON encoding interface
+func (i InternalError) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(i.code),
+		MessageFieldName: jsonMessage(i.message),
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&i)
+	}
+	return json.Marshal(m)
+}
+
+// Internal creates a new internal server error, serving clientMsg to clients while keeping its cause internally
+func Internal(clientMsg string, cause error) Error {
+	if clientMsg == "" {
+		clientMsg = "internal server error"
+	}
+	e := &InternalError{
+		code:    http.StatusInternalServerError,
+		Cause:   cause,
+		message: clientMsg,
+	}
+	if CaptureStack {
+		e.stack = callers(0)
+	}
+	return e
+}
+
+// QuotaExceededError represents an error for when a client exhausted its quota of requests, e.g. for the day.
+//
+// Unlike short-term rate limiting, clients should not retry before the quota is reset.
+type QuotaExceededError struct {
+	code    int32
+	ResetAt time.Time
+	message string
+}
+
+func (q *QuotaExceededError) Error() string {
+	return errorMessage(q.message)
+}
+
+// Code the error code
+func (q *QuotaExceededError) Code() int32 {
+	return q.code
+}
+
+// HTTPStatus returns the HTTP status this error is served with
+func (q *QuotaExceededError) HTTPStatus() int {
+	return http.StatusTooManyRequests
+}
+
+// Headers returns the X-RateLimit-Reset header, as the Unix time at which the quota is reset
+func (q *QuotaExceededError) Headers() http.Header {
+	return http.Header{"X-Ratelimit-Reset": []string{strconv.FormatInt(q.ResetAt.Unix(), 10)}}
+}
+
+// Retryable tells that the request should not be retried before the quota is reset
+func (q *QuotaExceededError) Retryable() bool {
+	return false
+}
+
+// MarshalJSON implements the JSON encoding interface
+func (q QuotaExceededError) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(q.code),
+		MessageFieldName: jsonMessage(q.message),
+		"resetAt":        q.ResetAt.UTC().Format(time.RFC3339),
+	}
+	if IncludeRetryable {
+		m["retryable"] = q.Retryable()
+	}
+	return json.Marshal(m)
+}
+
+// QuotaExceeded creates a new error for when a client exhausted its quota of requests until resetAt
+func QuotaExceeded(resetAt time.Time, message string) Error {
+	if message == "" {
+		message = "Quota exceeded"
+	}
+	return &QuotaExceededError{
+		code:    QuotaExceededCode,
+		ResetAt: resetAt,
+		message: message,
+	}
+}
+
 // ServeError implements the http error handler interface
 func ServeError(rw http.ResponseWriter, r *http.Request, err error) {
-	rw.Header().Set("Content-Type", "application/json")
+	_, _ = ServeErrorN(rw, r, err)
+}
+
+// ServeErrorN serves an error like ServeError and returns the number of bytes written to the body and any write error
+func ServeErrorN(rw http.ResponseWriter, r *http.Request, err error) (int, error) {
+	if ErrorLogger != nil {
+		ErrorLogger(r, err)
+	}
+	err = transformError(err)
+	if c, ok := err.(*CompositeError); ok && canStream(r, c) {
+		return streamComposite(rw, r, c)
+	}
+	return renderError(rw, r, err)
+}
+
+// RenderError returns the status, the Content-Type and the body ServeError would write for an error and a request.
+//
+// The request may be nil. The ErrorLogger is not called.
+func RenderError(err error, r *http.Request) (status int, contentType string, body []byte) {
+	res := bufferError(make(http.Header), r, transformError(err))
+	return res.status, res.header.Get("Content-Type"), res.body.Bytes()
+}
+
+// transformError applies the ErrorTransformer, if any
+func transformError(err error) error {
+	if ErrorTransformer == nil {
+		return err
+	}
+	if replacement := ErrorTransformer(err); replacement != nil {
+		return replacement
+	}
+	return err
+}
+
+// renderError renders an error in memory, then writes the resulting response
+func renderError(rw http.ResponseWriter, r *http.Request, err error) (int, error) {
+	res := bufferError(rw.Header().Clone(), r, err)
+	header := rw.Header()
+	for k, vs := range res.header {
+		header[k] = vs
+	}
+	rw.WriteHeader(res.status)
+	if res.body.Len() == 0 {
+		return 0, nil
+	}
+	return rw.Write(res.body.Bytes())
+}
+
+func bufferError(header http.Header, r *http.Request, err error) *bufferedResponse {
+	res := &bufferedResponse{header: header}
+	_, _ = serveError(res, r, err)
+	return res
+}
+
+// RecoverAndServe recovers from a panic and serves PanicError. It must be deferred, e.g. in a recovery middleware:
+//
+//	defer errors.RecoverAndServe(rw, r)
+//
+// The recovered panic is reported to the ErrorLogger, if any. http.ErrAbortHandler is not recovered.
+func RecoverAndServe(rw http.ResponseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	if ErrorLogger != nil {
+		var err error
+		if e, ok := v.(error); ok {
+			err = fmt.Errorf("panic: %w", e)
+		} else {
+			err = fmt.Errorf("panic: %v", v)
+		}
+		ErrorLogger(r, err)
+	}
+	_, _ = renderError(rw, r, PanicError)
+}
+
+func serveError(rw http.ResponseWriter, r *http.Request, err error) (int, error) {
 	switch e := err.(type) {
 	case *CompositeError:
-		er := flattenComposite(e)
-		// strips composite errors to first element only
-		if len(er.Errors) > 0 {
-			ServeError(rw, r, er.Errors[0])
-		} else {
-			// guard against empty CompositeError (invalid construct)
-			ServeError(rw, r, nil)
+		if e != nil && e.keepCode {
+			b, _ := e.MarshalJSON()
+			return writeErrorResponse(rw, r, e.HTTPStatus(), e, b)
+		}
+		// strips composite errors to first element only, with errors taking precedence over warnings.
+		// An empty CompositeError (invalid construct) yields nil and is served as an unknown error.
+		if errs, _ := e.SplitWarnings(); len(errs) > 0 {
+			return serveError(rw, r, errs[0])
 		}
-	case *MethodNotAllowedError:
-		rw.Header().Add("Allow", strings.Join(e.Allowed, ","))
-		rw.WriteHeader(asHTTPCode(int(e.Code())))
-		if r == nil || r.Method != http.MethodHead {
-			_, _ = rw.Write(errorAsJSON(e))
+		return serveError(rw, r, e.First())
+	case *APIVerificationFailed:
+		if e == nil {
+			return serveError(rw, r, nil)
 		}
+		b, _ := e.MarshalJSON()
+		return writeErrorResponse(rw, r, e.HTTPStatus(), e, b)
+	case *QuotaExceededError:
+		if e == nil {
+			return serveError(rw, r, nil)
+		}
+		copyHeaders(rw.Header(), e.Headers())
+		b, _ := e.MarshalJSON()
+		return writeErrorResponse(rw, r, e.HTTPStatus(), e, b)
 	case Error:
 		value := reflect.ValueOf(e)
 		if value.Kind() == reflect.Ptr && value.IsNil() {
-			rw.WriteHeader(http.StatusInternalServerError)
-			_, _ = rw.Write(errorAsJSON(New(http.StatusInternalServerError, "Unknown error")))
-			return
+			return serveError(rw, r, nil)
 		}
-		rw.WriteHeader(asHTTPCode(int(e.Code())))
-		if r == nil || r.Method != http.MethodHead {
-			_, _ = rw.Write(errorAsJSON(e))
+		if he, ok := e.(HTTPHeaderError); ok {
+			copyHeaders(rw.Header(), he.Headers())
 		}
+		return writeErrorResponse(rw, r, httpStatus(e), e, errorAsJSON(e))
 	case nil:
-		rw.WriteHeader(http.StatusInternalServerError)
-		_, _ = rw.Write(errorAsJSON(New(http.StatusInternalServerError, "Unknown error")))
+		unknown := New(http.StatusInternalServerError, "Unknown error")
+		return writeErrorResponse(rw, r, http.StatusInternalServerError, unknown, errorAsJSON(unknown))
 	default:
-		rw.WriteHeader(http.StatusInternalServerError)
-		if r == nil || r.Method != http.MethodHead {
-			_, _ = rw.Write(errorAsJSON(New(http.StatusInternalServerError, err.Error())))
+		var invalidJSON *InvalidJSONError
+		if errors.As(err, &invalidJSON) {
+			return serveError(rw, r, invalidJSON)
+		}
+		if isJSONDecodingError(err) {
+			return serveError(rw, r, InvalidJSON(err))
 		}
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, context.Canceled):
+			status = CanceledHTTPCode
+		case errors.Is(err, context.DeadlineExceeded):
+			status = DeadlineExceededHTTPCode
+		}
+		internal := New(int32(status), err.Error())
+		return writeErrorResponse(rw, r, status, internal, errorAsJSON(internal))
+	}
+}
+
+// isJSONDecodingError tells if err, possibly wrapped, is a JSON syntax error.
+//
+// Type mismatches and I/O errors are not reported as invalid JSON.
+func isJSONDecodingError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	return errors.As(err, &syntaxErr)
+}
+
+// WouldServe returns the status and the body ServeError would write for an error, without a request.
+//
+// The ErrorLogger is not called.
+func WouldServe(err error) (status int, body []byte) {
+	status, _, body = RenderError(err, nil)
+	return status, body
+}
+
+// bufferedResponse is an in-memory http.ResponseWriter
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// writeErrorResponse writes the status and the body of an error response, with its Content-Type and Content-Length.
+//
+// The JSON body is used unless another renderer is negotiated with the request or JSONRenderer is set.
+// The body is omitted for HEAD requests. Responses with a status which forbids a body, e.g. 304, only have a status.
+func writeErrorResponse(rw http.ResponseWriter, r *http.Request, status int, err Error, jsonBody []byte) (int, error) {
+	if !bodyAllowed(status) {
+		rw.WriteHeader(status)
+		return 0, nil
+	}
+	contentType, body := "application/json", jsonBody
+	rr, negotiated := negotiateRenderer(r)
+	switch {
+	case negotiated:
+		contentType, body = rr.contentType, rr.render(r, status, err)
+	case JSONRenderer != nil:
+		body = JSONRenderer(r, status, err)
+	case r != nil:
+		body = withRequestInfo(body, r)
+	}
+	if AllowJSONP && r != nil && contentType == "application/json" {
+		if callback := jsonpCallback(r); callback != "" {
+			contentType = "application/javascript"
+			body = append(append([]byte(callback+"("), body...), ");"...)
+			// scripts are always labeled, even with SkipContentTypeHeader, so that browsers do not sniff them
+			rw.Header().Set("Content-Type", contentType)
+			rw.Header().Set("X-Content-Type-Options", "nosniff")
+		}
+	}
+	if !SkipContentTypeHeader {
+		rw.Header().Set("Content-Type", contentType)
+	}
+	addVary(rw.Header(), r)
+	addDocLink(rw.Header(), err.Code())
+	if AppendNewline {
+		body = append(body, '\n')
+	}
+	rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	rw.WriteHeader(status)
+	if r != nil && r.Method == http.MethodHead {
+		return 0, nil
+	}
+	return rw.Write(body)
+}
+
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// jsonpCallback returns the callback query parameter of the request, provided it is a safe JavaScript identifier
+func jsonpCallback(r *http.Request) string {
+	if r.URL == nil {
+		return ""
+	}
+	callback := r.URL.Query().Get("callback")
+	if len(callback) > 128 || !jsonpCallbackPattern.MatchString(callback) {
+		return ""
+	}
+	return callback
+}
+
+// bodyAllowed tells if a response with this status may have a body
+func bodyAllowed(status int) bool {
+	switch {
+	case status >= 100 && status < 200:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	default:
+		return true
+	}
+}
+
+// addDocLink adds a Link header to the documentation of an error code, when DocURLForCode resolves one
+func addDocLink(header http.Header, code int32) {
+	if DocURLForCode == nil {
+		return
+	}
+	if url := DocURLForCode(code); url != "" {
+		header.Add("Link", "<"+url+`>; rel="help"`)
+	}
+}
+
+func copyHeaders(dst, src http.Header) {
+	for k, vs := range src {
+		dst.Del(k)
+		for _, v := range vs {
+			dst.Add(k, v)
+		}
+	}
+}
+
+// withRequestInfo appends the method and path of the request to a JSON object when IncludeRequestInfo is enabled,
+// as well as the trace ID of the request when TraceIDFromRequest is set
+func withRequestInfo(body []byte, r *http.Request) []byte {
+	if IncludeRequestInfo {
+		var path string
+		if r.URL != nil {
+			path = r.URL.Path
+		}
+		body = appendJSONField(body, "method", r.Method)
+		body = appendJSONField(body, "path", path)
+	}
+	if TraceIDFromRequest != nil {
+		if traceID := TraceIDFromRequest(r); traceID != "" {
+			body = appendJSONField(body, "traceId", traceID)
+		}
+	}
+	return body
+}
+
+// appendJSONField appends a field to a JSON object
+func appendJSONField(body []byte, key string, value interface{}) []byte {
+	if len(body) < 2 || body[len(body)-1] != '}' {
+		return body
+	}
+	escapedKey, _ := json.Marshal(key)
+	escapedValue, err := json.Marshal(value)
+	if err != nil {
+		return body
+	}
+
+	res := make([]byte, 0, len(body)+len(escapedKey)+len(escapedValue)+2)
+	res = append(res, body[:len(body)-1]...)
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		res = append(res, ',')
+	}
+	res = append(res, escapedKey...)
+	res = append(res, ':')
+	res = append(res, escapedValue...)
+	return append(res, '}')
+}
+
+// httpStatus resolves the HTTP status to serve an error with.
+//
+// Errors exposing an HTTPStatus() method decide on their status, otherwise the code is used.
+func httpStatus(e Error) int {
+	if se, ok := e.(interface{ HTTPStatus() int }); ok {
+		return se.HTTPStatus()
 	}
+	return asHTTPCode(int(e.Code()))
 }
 
 func asHTTPCode(input int) int {
@@ -15,12 +15,17 @@
 package errors
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,6 +35,15 @@ type customError struct {
 	apiError
 }
 
+type headerError struct {
+	apiError
+	headers http.Header
+}
+
+func (h *headerError) Headers() http.Header {
+	return h.headers
+}
+
 func TestServeError(t *testing.T) {
 	// method not allowed wins
 	// err abides by the Error interface
@@ -48,6 +62,7 @@ func TestServeError(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, recorder.Code)
 	// assert.Equal(t, "application/json", recorder.Header().Get("content-type"))
 	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+	assert.Equal(t, "34", recorder.Header().Get("Content-Length"))
 
 	// renders mapped status code from error when present
 	err = InvalidTypeName("someType")
@@ -121,6 +136,25 @@ func TestServeError(t *testing.T) {
 	assert.Equal(t, CompositeErrorCode, recorder.Code)
 	assert.Equal(t, `{"code":600,"message":"myApiError"}`, recorder.Body.String())
 
+	// errors take precedence over warnings
+	compositeErr = CompositeValidationError(
+		Required("a", "query", nil).AsWarning(),
+		New(http.StatusBadRequest, "myApiError"),
+	)
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, compositeErr)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, `{"code":400,"message":"myApiError"}`, recorder.Body.String())
+
+	// unprocessable entity keeps its code and serves all errors
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, UnprocessableEntity(New(http.StatusBadRequest, "myApiError"), InvalidTypeName("someType")))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.JSONEq(t,
+		`{"code":422,"message":"validation failure list","errors":[{"code":400,"message":"myApiError"},{"code":601,"message":"someType is an invalid type name","in":"","name":"","value":"someType","values":null,"keyword":"type"}]}`,
+		recorder.Body.String(),
+	)
+
 	// check guard against empty CompositeError (e.g. nil Error interface)
 	compositeErr = &CompositeError{
 		Errors: []error{
@@ -140,6 +174,17 @@ func TestServeError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	assert.Equal(t, `{"code":500,"message":"Unknown error"}`, recorder.Body.String())
 
+	// error with custom headers
+	hErr := &headerError{
+		apiError: apiError{code: http.StatusTooManyRequests, message: "slow down"},
+		headers:  http.Header{"retry-after": []string{"30"}},
+	}
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, hErr)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "30", recorder.Header().Get("Retry-After"))
+	assert.Equal(t, `{"code":429,"message":"slow down"}`, recorder.Body.String())
+
 	recorder = httptest.NewRecorder()
 	var z *customError
 	ServeError(recorder, nil, z)
@@ -153,6 +198,15 @@ func TestAPIErrors(t *testing.T) {
 	assert.EqualValues(t, 402, err.Code())
 	assert.EqualValues(t, "this failed yada", err.Error())
 
+	err = New(0, "x")
+	require.Error(t, err)
+	assert.EqualValues(t, DefaultHTTPCode, err.Code())
+	assert.EqualValues(t, "x", err.Error())
+
+	err = New(-1, "x")
+	require.Error(t, err)
+	assert.EqualValues(t, DefaultHTTPCode, err.Code())
+
 	err = NotFound("this failed %d", 1)
 	require.Error(t, err)
 	assert.EqualValues(t, http.StatusNotFound, err.Code())
@@ -163,6 +217,36 @@ func TestAPIErrors(t *testing.T) {
 	assert.EqualValues(t, http.StatusNotFound, err.Code())
 	assert.EqualValues(t, "Not found", err.Error())
 
+	err = NotAcceptable("language %s is not available", "fr")
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusNotAcceptable, err.Code())
+	assert.EqualValues(t, "language fr is not available", err.Error())
+
+	err = NotAcceptable("")
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusNotAcceptable, err.Code())
+	assert.EqualValues(t, "Not acceptable", err.Error())
+
+	err = RequestTimeout("")
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusRequestTimeout, err.Code())
+	assert.EqualValues(t, "Request timeout", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, RequestTimeout("reading body took more than %ds", 30))
+	assert.Equal(t, http.StatusRequestTimeout, recorder.Code)
+	assert.Equal(t, `{"code":408,"message":"reading body took more than 30s"}`, recorder.Body.String())
+
+	err = Locked("")
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusLocked, err.Code())
+	assert.EqualValues(t, "Locked", err.Error())
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, Locked("document %s is locked", "a.txt"))
+	assert.Equal(t, http.StatusLocked, recorder.Code)
+	assert.Equal(t, `{"code":423,"message":"document a.txt is locked"}`, recorder.Body.String())
+
 	err = NotImplemented("not implemented")
 	require.Error(t, err)
 	assert.EqualValues(t, http.StatusNotImplemented, err.Code())
@@ -182,6 +266,17 @@ func TestAPIErrors(t *testing.T) {
 	require.Error(t, err)
 	assert.EqualValues(t, http.StatusNotAcceptable, err.Code())
 	assert.EqualValues(t, "unsupported media type requested, only [application/json application/x-yaml] are available", err.Error())
+
+	err = InvalidCharset("iso-8859-1", []string{"utf-8", "utf-16"})
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusNotAcceptable, err.Code())
+	assert.EqualValues(t, "unsupported charset requested 'iso-8859-1', only [utf-8 utf-16] are available", err.Error())
+	assert.Equal(t, []interface{}{"utf-8", "utf-16"}, err.(*Validation).Values)
+
+	err = MissingHeader("X-Request-Id")
+	require.Error(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.Code())
+	assert.EqualValues(t, "missing required header 'X-Request-Id'", err.Error())
 }
 
 func TestValidateName(t *testing.T) {
@@ -209,6 +304,21 @@ func TestValidateName(t *testing.T) {
 	vv = v.ValidateName("myNewName")
 	assert.EqualValues(t, "myNewName", vv.Name)
 	assert.EqualValues(t, "myNewNamemyMessage", vv.message)
+
+	// nested path
+	v = &Validation{Name: "zip", message: "zip in body is required"}
+	vv = v.ValidateName("customer", "", "address")
+	assert.EqualValues(t, "customer.address.zip", vv.Name)
+	assert.EqualValues(t, "customer.address.zip in body is required", vv.message)
+
+	v = &Validation{message: " in body is required"}
+	vv = v.ValidateName("customer", "address")
+	assert.EqualValues(t, "customer.address", vv.Name)
+	assert.EqualValues(t, "customer.address in body is required", vv.message)
+
+	// all segments empty
+	vv = v.ValidateName("", "")
+	assert.EqualValues(t, "customer.address", vv.Name)
 }
 
 func TestMarshalJSON(t *testing.T) {
@@ -246,8 +356,784 @@ func TestMarshalJSON(t *testing.T) {
 	require.NoError(t, err)
 	assert.JSONEq(t, fmt.Sprintf(`{"code":1,"message":"a","errors":[%s]}`, expectedJSON), string(jazon))
 
+	jazon, err = c.MarshalJSONVerbose(true)
+	require.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"code":1,"message":"a","errors":[%s]}`, expectedJSON), string(jazon))
+
+	c = CompositeError{Errors: []error{e, CompositeValidationError(New(600, "b"))}, code: 1, message: "a"}
+	jazon, err = c.MarshalJSONVerbose(false)
+	require.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"code":1,"message":"a","errors":["%s","b"]}`, expectedMessage), string(jazon))
+
 	p := ParseError{code: 1, message: "x", Name: "a", In: "b", Value: "c", Reason: errors.New("d")}
 	jazon, err = p.MarshalJSON()
 	require.NoError(t, err)
 	assert.JSONEq(t, `{"code":1,"message":"x","name":"a","in":"b","value":"c","reason":"d"}`, string(jazon))
 }
+
+func TestValidationWithSource(t *testing.T) {
+	e := Required("id", "header", nil).WithSource("X-Request-Id")
+	assert.Equal(t, "X-Request-Id", e.Source)
+
+	jazon, err := e.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t,
+		`{"code":602,"message":"id in header is required","in":"header","name":"id","values":null,"source":"X-Request-Id","keyword":"required"}`,
+		string(jazon),
+	)
+}
+
+func TestValidationWithHint(t *testing.T) {
+	e := InvalidType("birthday", "query", "date", "12/31/2000")
+	assert.Equal(t, "expected YYYY-MM-DD", e.Hint)
+
+	jazon, err := e.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"hint":"expected YYYY-MM-DD"`)
+
+	e = InvalidType("age", "query", "integer", "x")
+	assert.Empty(t, e.Hint)
+	jazon, err = e.MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(jazon), `"hint"`)
+
+	e = e.WithHint("expected a whole number")
+	assert.Equal(t, "expected a whole number", e.Hint)
+}
+
+func TestFieldError(t *testing.T) {
+	for _, err := range []error{
+		Required("id", "query", nil),
+		NewParseError("id", "query", "x", errors.New("not a number")),
+	} {
+		fe, ok := err.(FieldError)
+		require.True(t, ok)
+		assert.Equal(t, "id", fe.FieldName())
+		assert.Equal(t, "query", fe.Location())
+	}
+
+	_, ok := NotFound("").(FieldError)
+	assert.False(t, ok)
+}
+
+func TestValidationWithSchemaID(t *testing.T) {
+	e := Required("id", "body", nil).WithSchemaID("https://example.com/schemas/pet.json#/properties/id")
+	assert.Equal(t, "https://example.com/schemas/pet.json#/properties/id", e.SchemaID)
+
+	jazon, err := e.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"schema":"https://example.com/schemas/pet.json#/properties/id"`)
+
+	jazon, err = Required("id", "body", nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(jazon), `"schema"`)
+}
+
+func TestValidationWithCauses(t *testing.T) {
+	badDomain, tooLong := errors.New("bad domain"), errors.New("too long")
+	e := InvalidType("email", "body", "email", "x@invalid").WithCauses(badDomain, nil, tooLong)
+	require.ErrorIs(t, e, badDomain)
+	require.ErrorIs(t, e, tooLong)
+	assert.Equal(t, []error{badDomain, tooLong}, e.Unwrap())
+
+	jazon, err := e.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"reasons":["bad domain","too long"]`)
+
+	jazon, err = Required("email", "body", nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(jazon), `"reasons"`)
+}
+
+func TestCodeAsString(t *testing.T) {
+	oldCodeAsString := CodeAsString
+	defer func() { CodeAsString = oldCodeAsString }()
+	CodeAsString = true
+
+	jazon, err := apiError{code: 404, message: "a"}.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"404","message":"a"}`, string(jazon))
+
+	jazon, err = MethodNotAllowedError{code: 405, message: "a", Allowed: []string{"POST"}}.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"405","message":"a","allowed":["POST"]}`, string(jazon))
+
+	jazon, err = CompositeError{code: 422, message: "a"}.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":"422","message":"a","errors":null}`, string(jazon))
+
+	jazon, err = InvalidTypeName("x").MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"code":"601"`)
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, `{"code":"404","message":"Not found"}`, recorder.Body.String())
+}
+
+func TestServeErrorAppendNewline(t *testing.T) {
+	oldAppendNewline := AppendNewline
+	defer func() { AppendNewline = oldAppendNewline }()
+	AppendNewline = true
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "{\"code\":404,\"message\":\"Not found\"}\n", recorder.Body.String())
+	assert.Equal(t, "35", recorder.Header().Get("Content-Length"))
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, httptest.NewRequest(http.MethodHead, "/", nil), NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestServeErrorIncludeRequestInfo(t *testing.T) {
+	oldIncludeRequestInfo := IncludeRequestInfo
+	defer func() { IncludeRequestInfo = oldIncludeRequestInfo }()
+	IncludeRequestInfo = true
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, httptest.NewRequest(http.MethodGet, "/pets/1?token=secret", nil), NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, `{"code":404,"message":"Not found","method":"GET","path":"/pets/1"}`, recorder.Body.String())
+
+	// without a request, the body is unchanged
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+}
+
+type failingWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (f failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestServeErrorN(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	n, err := ServeErrorN(recorder, nil, NotFound(""))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, recorder.Body.Len(), n)
+	assert.Equal(t, 34, n)
+
+	recorder = httptest.NewRecorder()
+	n, err = ServeErrorN(recorder, httptest.NewRequest(http.MethodHead, "/", nil), NotFound(""))
+	require.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	n, err = ServeErrorN(failingWriter{httptest.NewRecorder()}, nil, NotFound(""))
+	require.Error(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(nil, nil))
+	assert.False(t, Equal(nil, NotFound("")))
+	assert.False(t, Equal(errors.New("a"), errors.New("a")))
+
+	assert.True(t, Equal(NotFound("a"), NotFound("b")))
+	assert.False(t, Equal(NotFound(""), NotImplemented("")))
+
+	assert.True(t, Equal(TooLong("a", "query", 2, "abc"), TooLong("a", "query", 3, "abcd")))
+	assert.False(t, Equal(TooLong("a", "query", 2, "abc"), TooLong("b", "query", 2, "abc")))
+	assert.False(t, Equal(TooLong("a", "query", 2, "abc"), TooLong("a", "body", 2, "abc")))
+	assert.False(t, Equal(TooLong("a", "query", 2, "abc"), New(TooLongFailCode, "a")))
+}
+
+func TestCaptureStack(t *testing.T) {
+	err := New(http.StatusInternalServerError, "boom")
+	assert.Empty(t, err.(*apiError).StackTrace())
+
+	oldCaptureStack := CaptureStack
+	defer func() { CaptureStack = oldCaptureStack }()
+	CaptureStack = true
+
+	// the top frame is the caller of the constructor, whatever the constructor
+	for _, err := range []Error{
+		New(http.StatusInternalServerError, "boom"),
+		NewWithStatus(10500, http.StatusInternalServerError, "boom"),
+		NotImplemented("boom"),
+		FromHTTPStatus(http.StatusNotImplemented, "boom"),
+		FromHTTPStatus(http.StatusBadGateway, "boom"),
+		Internal("", errors.New("boom")),
+	} {
+		stack := err.(interface{ StackTrace() []uintptr }).StackTrace()
+		require.NotEmpty(t, stack)
+		frame, _ := runtime.CallersFrames(stack).Next()
+		assert.Equal(t, "github.com/go-openapi/errors.TestCaptureStack", frame.Function)
+	}
+
+	err = NotFound("")
+	assert.Empty(t, err.(*apiError).StackTrace())
+
+	// the stack is not serialized
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, New(http.StatusInternalServerError, "boom"))
+	assert.Equal(t, `{"code":500,"message":"boom"}`, recorder.Body.String())
+}
+
+func TestErrorLogger(t *testing.T) {
+	oldErrorLogger := ErrorLogger
+	defer func() { ErrorLogger = oldErrorLogger }()
+
+	var logged []error
+	ErrorLogger = func(_ *http.Request, err error) {
+		logged = append(logged, err)
+	}
+
+	err := CompositeValidationError(NotFound(""))
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, []error{err}, logged)
+}
+
+func TestRecoverAndServe(t *testing.T) {
+	oldErrorLogger := ErrorLogger
+	defer func() { ErrorLogger = oldErrorLogger }()
+
+	var logged []error
+	ErrorLogger = func(_ *http.Request, err error) {
+		logged = append(logged, err)
+	}
+
+	handler := func(rw http.ResponseWriter, r *http.Request, v interface{}) {
+		defer RecoverAndServe(rw, r)
+		if v != nil {
+			panic(v)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler(recorder, nil, "secret database password")
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, `{"code":500,"message":"internal server error"}`, recorder.Body.String())
+	require.Len(t, logged, 1)
+	assert.Equal(t, "panic: secret database password", logged[0].Error())
+
+	cause := errors.New("boom")
+	recorder = httptest.NewRecorder()
+	handler(recorder, nil, cause)
+	require.Len(t, logged, 2)
+	assert.ErrorIs(t, logged[1], cause)
+
+	// no panic
+	recorder = httptest.NewRecorder()
+	handler(recorder, nil, nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Len(t, logged, 2)
+
+	oldPanicError := PanicError
+	defer func() { PanicError = oldPanicError }()
+	PanicError = New(http.StatusServiceUnavailable, "try again later")
+
+	recorder = httptest.NewRecorder()
+	handler(recorder, nil, "x")
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler(httptest.NewRecorder(), nil, http.ErrAbortHandler)
+	})
+}
+
+func TestFromHTTPStatus(t *testing.T) {
+	err := FromHTTPStatus(http.StatusNotFound, "")
+	assert.EqualValues(t, http.StatusNotFound, err.Code())
+	assert.Equal(t, "Not found", err.Error())
+
+	err = FromHTTPStatus(http.StatusNotImplemented, "%s is not implemented", "x")
+	assert.EqualValues(t, http.StatusNotImplemented, err.Code())
+	assert.Equal(t, "x is not implemented", err.Error())
+
+	err = FromHTTPStatus(http.StatusNotAcceptable, "")
+	assert.EqualValues(t, http.StatusNotAcceptable, err.Code())
+	assert.Equal(t, "Not acceptable", err.Error())
+
+	err = FromHTTPStatus(http.StatusUnauthorized, "")
+	assert.EqualValues(t, http.StatusUnauthorized, err.Code())
+	assert.Equal(t, "Unauthenticated", err.Error())
+
+	err = FromHTTPStatus(http.StatusBadGateway, "upstream %d failed", 1)
+	assert.EqualValues(t, http.StatusBadGateway, err.Code())
+	assert.Equal(t, "upstream 1 failed", err.Error())
+
+	err = FromHTTPStatus(http.StatusNotFound, "100% done")
+	assert.EqualValues(t, http.StatusNotFound, err.Code())
+	assert.Equal(t, "100% done", err.Error())
+
+	err = FromHTTPStatus(http.StatusLocked, "%s is 100%% done", "upload")
+	assert.EqualValues(t, http.StatusLocked, err.Code())
+	assert.Equal(t, "upload is 100% done", err.Error())
+}
+
+func TestNewWithStatus(t *testing.T) {
+	err := NewWithStatus(10023, http.StatusBadRequest, "invalid %s", "thing")
+	assert.EqualValues(t, 10023, err.Code())
+	assert.Equal(t, "invalid thing", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, `{"code":10023,"message":"invalid thing"}`, recorder.Body.String())
+}
+
+func TestServeErrorContext(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, context.Canceled)
+	assert.Equal(t, CanceledHTTPCode, recorder.Code)
+	assert.Equal(t, `{"code":499,"message":"context canceled"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, fmt.Errorf("calling upstream: %w", context.DeadlineExceeded))
+	assert.Equal(t, http.StatusGatewayTimeout, recorder.Code)
+	assert.Equal(t, `{"code":504,"message":"calling upstream: context deadline exceeded"}`, recorder.Body.String())
+
+	oldCanceledHTTPCode := CanceledHTTPCode
+	defer func() { CanceledHTTPCode = oldCanceledHTTPCode }()
+	CanceledHTTPCode = http.StatusServiceUnavailable
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, context.Canceled)
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestUpgradeRequired(t *testing.T) {
+	err := UpgradeRequired("HTTP/2.0")
+	assert.EqualValues(t, http.StatusUpgradeRequired, err.Code())
+	assert.Equal(t, "upgrade to HTTP/2.0 required", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusUpgradeRequired, recorder.Code)
+	assert.Equal(t, "HTTP/2.0", recorder.Header().Get("Upgrade"))
+	assert.Equal(t, "Upgrade", recorder.Header().Get("Connection"))
+	assert.Equal(t, `{"code":426,"message":"upgrade to HTTP/2.0 required"}`, recorder.Body.String())
+}
+
+func TestUnavailableForLegalReasons(t *testing.T) {
+	err := UnavailableForLegalReasons("")
+	assert.EqualValues(t, http.StatusUnavailableForLegalReasons, err.Code())
+	assert.Equal(t, "Unavailable for legal reasons", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, recorder.Code)
+	assert.Empty(t, recorder.Header().Get("Link"))
+	assert.Equal(t, `{"code":451,"message":"Unavailable for legal reasons"}`, recorder.Body.String())
+
+	err = UnavailableForLegalReasonsBlockedBy("https://authority.example.org", "not available in %s", "XX")
+	assert.Equal(t, "not available in XX", err.Error())
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, recorder.Code)
+	assert.Equal(t, `<https://authority.example.org>; rel="blocked-by"`, recorder.Header().Get("Link"))
+}
+
+func TestInternal(t *testing.T) {
+	oldErrorLogger := ErrorLogger
+	defer func() { ErrorLogger = oldErrorLogger }()
+
+	var logged []error
+	ErrorLogger = func(_ *http.Request, err error) {
+		logged = append(logged, err)
+	}
+
+	cause := errors.New("connection refused: db.internal:5432")
+	err := Internal("", cause)
+	assert.EqualValues(t, http.StatusInternalServerError, err.Code())
+	assert.Equal(t, "internal server error", err.Error())
+	require.ErrorIs(t, err, cause)
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.Equal(t, `{"code":500,"message":"internal server error"}`, recorder.Body.String())
+	require.Len(t, logged, 1)
+	assert.Equal(t, cause, errors.Unwrap(logged[0]))
+
+	jazon, jerr := json.Marshal(Internal("try again later", cause))
+	require.NoError(t, jerr)
+	assert.JSONEq(t, `{"code":500,"message":"try again later"}`, string(jazon))
+}
+
+func TestNotModified(t *testing.T) {
+	err := NotModified()
+	assert.EqualValues(t, http.StatusNotModified, err.Code())
+	assert.Equal(t, "Not modified", err.Error())
+
+	recorder := httptest.NewRecorder()
+	recorder.Header().Set("ETag", `"v1"`)
+	n, werr := ServeErrorN(recorder, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	require.NoError(t, werr)
+	assert.Zero(t, n)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+	assert.Empty(t, recorder.Body.String())
+	assert.Empty(t, recorder.Header().Get("Content-Type"))
+	assert.Empty(t, recorder.Header().Get("Content-Length"))
+	assert.Equal(t, `"v1"`, recorder.Header().Get("ETag"))
+}
+
+func TestQuotaExceeded(t *testing.T) {
+	resetAt := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	err := QuotaExceeded(resetAt, "")
+	assert.EqualValues(t, QuotaExceededCode, err.Code())
+	assert.Equal(t, "Quota exceeded", err.Error())
+
+	jazon, jerr := json.Marshal(err)
+	require.NoError(t, jerr)
+	assert.JSONEq(t,
+		fmt.Sprintf(`{"code":%d,"message":"Quota exceeded","resetAt":"2024-05-01T00:00:00Z"}`, QuotaExceededCode),
+		string(jazon),
+	)
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, QuotaExceeded(resetAt, "daily quota exhausted"))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Equal(t, "1714521600", recorder.Header().Get("X-RateLimit-Reset"))
+	assert.Equal(t,
+		fmt.Sprintf(`{"code":%d,"message":"daily quota exhausted","resetAt":"2024-05-01T00:00:00Z"}`, QuotaExceededCode),
+		recorder.Body.String(),
+	)
+
+	// clients should not retry before the reset
+	oldIncludeRetryable := IncludeRetryable
+	defer func() { IncludeRetryable = oldIncludeRetryable }()
+	IncludeRetryable = true
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, QuotaExceeded(resetAt, "daily quota exhausted"))
+	assert.Contains(t, recorder.Body.String(), `"retryable":false`)
+	assert.False(t, err.(*QuotaExceededError).Retryable())
+
+	// served as the first error of a composite
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, CompositeValidationError(QuotaExceeded(resetAt, "")))
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"retryable":false`)
+}
+
+func TestRetryable(t *testing.T) {
+	assert.True(t, New(http.StatusServiceUnavailable, "a").(*apiError).Retryable())
+	assert.True(t, New(http.StatusTooManyRequests, "a").(*apiError).Retryable())
+	assert.False(t, New(http.StatusBadRequest, "a").(*apiError).Retryable())
+	assert.False(t, New(InvalidTypeCode, "a").(*apiError).Retryable())
+
+	jazon, err := apiError{code: 503, message: "a"}.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":503,"message":"a"}`, string(jazon))
+
+	oldIncludeRetryable := IncludeRetryable
+	defer func() { IncludeRetryable = oldIncludeRetryable }()
+	IncludeRetryable = true
+
+	jazon, err = apiError{code: 503, message: "a"}.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"code":503,"message":"a","retryable":true}`, string(jazon))
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, `{"code":404,"message":"Not found","retryable":false}`, recorder.Body.String())
+
+	jazon, err = json.Marshal(Required("a", "body", nil))
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"retryable":false`)
+
+	jazon, err = json.Marshal(CompositeValidationError(Required("a", "body", nil)))
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(jazon), `"retryable":false}`))
+
+	jazon, err = CompositeValidationError(Required("a", "body", nil)).MarshalJSONVerbose(false)
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"retryable":false`)
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, Internal("", errors.New("db is down")))
+	assert.Equal(t, `{"code":500,"message":"internal server error","retryable":true}`, recorder.Body.String())
+}
+
+func TestWouldServe(t *testing.T) {
+	status, body := WouldServe(New(http.StatusConflict, "conflict"))
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Equal(t, `{"code":409,"message":"conflict"}`, string(body))
+
+	status, body = WouldServe(CompositeValidationError(InvalidTypeName("someType")))
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, `{"code":601,"message":"someType is an invalid type name"}`, string(body))
+
+	status, body = WouldServe(errors.New("some error"))
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Equal(t, `{"code":500,"message":"some error"}`, string(body))
+}
+
+func TestServeErrorTraceID(t *testing.T) {
+	oldTraceIDFromRequest := TraceIDFromRequest
+	defer func() { TraceIDFromRequest = oldTraceIDFromRequest }()
+	TraceIDFromRequest = func(r *http.Request) string {
+		return r.Header.Get("X-Trace-Id")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Trace-Id", "4bf92f3577b34da6")
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, `{"code":404,"message":"Not found","traceId":"4bf92f3577b34da6"}`, recorder.Body.String())
+
+	// no trace ID
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, httptest.NewRequest(http.MethodGet, "/", nil), NotFound(""))
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+}
+
+func TestSanitizeMessages(t *testing.T) {
+	oldSanitizeMessages := SanitizeMessages
+	defer func() { SanitizeMessages = oldSanitizeMessages }()
+
+	err := Required("name\nINFO forged entry \x1b[31m", "query", nil)
+	assert.Contains(t, err.Error(), "\n")
+
+	SanitizeMessages = true
+	assert.Equal(t, `name\nINFO forged entry \x1b[31m in query is required`, err.Error())
+	assert.Equal(t, "héllo", New(400, "héllo").Error())
+
+	b, e := json.Marshal(New(400, "line1\r\nline2\t"))
+	require.NoError(t, e)
+	assert.Equal(t, `{"code":400,"message":"line1\\r\\nline2\\t"}`, string(b))
+
+	composite := CompositeValidationError(New(400, "a\nb"))
+	assert.Equal(t, "validation failure list:\na\\nb", composite.Error())
+}
+
+func TestServeErrorDocURL(t *testing.T) {
+	oldDocURLForCode := DocURLForCode
+	defer func() { DocURLForCode = oldDocURLForCode }()
+	DocURLForCode = func(code int32) string {
+		if code == RequiredFailCode {
+			return "https://example.com/errors/required"
+		}
+		return ""
+	}
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, CompositeValidationError(Required("id", "query", nil)))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, `<https://example.com/errors/required>; rel="help"`, recorder.Header().Get("Link"))
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Empty(t, recorder.Header().Get("Link"))
+}
+
+func TestAs400(t *testing.T) {
+	v := Required("id", "query", nil)
+	err := As400(CompositeValidationError(v, NotFound("")))
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, `{"code":602,"message":"id in query is required"}`, recorder.Body.String())
+	// the original error is left untouched
+	assert.Equal(t, http.StatusUnprocessableEntity, v.HTTPStatus())
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, As400(UnprocessableEntity(v)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, As422(New(http.StatusBadRequest, "bad")))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, `{"code":400,"message":"bad"}`, recorder.Body.String())
+
+	plain := errors.New("x")
+	assert.Equal(t, plain, As400(plain))
+	assert.NoError(t, As400(nil))
+}
+
+func TestServeErrorJSONP(t *testing.T) {
+	oldAllowJSONP := AllowJSONP
+	defer func() { AllowJSONP = oldAllowJSONP }()
+
+	r := httptest.NewRequest(http.MethodGet, "/?callback=jQuery.cb_12", nil)
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+
+	AllowJSONP = true
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/javascript", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, `jQuery.cb_12({"code":404,"message":"Not found"});`, recorder.Body.String())
+
+	// scripts are labeled even when the Content-Type header is skipped
+	oldSkipContentTypeHeader := SkipContentTypeHeader
+	defer func() { SkipContentTypeHeader = oldSkipContentTypeHeader }()
+	SkipContentTypeHeader = true
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, "application/javascript", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "nosniff", recorder.Header().Get("X-Content-Type-Options"))
+	SkipContentTypeHeader = false
+
+	// unsafe callbacks are ignored
+	for _, callback := range []string{"alert(1)//", "a..b", "1cb", "<script>"} {
+		r = httptest.NewRequest(http.MethodGet, "/", nil)
+		r.URL.RawQuery = url.Values{"callback": []string{callback}}.Encode()
+		recorder = httptest.NewRecorder()
+		ServeError(recorder, r, NotFound(""))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, err := range []error{
+		NotFound(""),
+		CompositeValidationError(Required("id", "query", nil)),
+		MethodNotAllowed("GET", []string{"POST"}),
+		errors.New("boom"),
+		nil,
+	} {
+		status, contentType, body := RenderError(err, r)
+
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, r, err)
+		assert.Equal(t, recorder.Code, status)
+		assert.Equal(t, recorder.Header().Get("Content-Type"), contentType)
+		assert.Equal(t, recorder.Body.Bytes(), body)
+	}
+
+	status, contentType, body := RenderError(NotFound(""), nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	assert.Equal(t, "application/json", contentType)
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, string(body))
+}
+
+func TestFieldNames(t *testing.T) {
+	oldCodeFieldName, oldMessageFieldName := CodeFieldName, MessageFieldName
+	defer func() { CodeFieldName, MessageFieldName = oldCodeFieldName, oldMessageFieldName }()
+	CodeFieldName, MessageFieldName = "status", "detail"
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, `{"detail":"Not found","status":404}`, recorder.Body.String())
+
+	jazon, err := Required("id", "query", nil).MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(jazon), `"detail":"id in query is required"`)
+	assert.Contains(t, string(jazon), `"status":602`)
+	assert.NotContains(t, string(jazon), `"message"`)
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, UnprocessableEntity(Required("id", "query", nil)))
+	assert.Contains(t, recorder.Body.String(), `"detail":"validation failure list"`)
+	assert.NotContains(t, recorder.Body.String(), `"code"`)
+}
+
+func TestMaxMessageLength(t *testing.T) {
+	oldMaxMessageLength, oldTruncateErrorStrings := MaxMessageLength, TruncateErrorStrings
+	defer func() { MaxMessageLength, TruncateErrorStrings = oldMaxMessageLength, oldTruncateErrorStrings }()
+	MaxMessageLength = 10
+
+	err := InvalidType("name", "query", "integer", strings.Repeat("é", 100))
+	assert.Greater(t, len(err.Error()), 100)
+
+	jazon, jerr := err.MarshalJSON()
+	require.NoError(t, jerr)
+	assert.Contains(t, string(jazon), `"message":"name in qu..."`)
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, New(http.StatusBadRequest, "é%s", strings.Repeat("x", 100)))
+	assert.Equal(t, `{"code":400,"message":"éxxxxxxxxx..."}`, recorder.Body.String())
+
+	// short messages are left untouched
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+
+	TruncateErrorStrings = true
+	assert.Equal(t, "name in qu...", err.Error())
+}
+
+func TestMethodNotAllowedOrder(t *testing.T) {
+	allow := []string{"PUT", "POST"}
+	err := MethodNotAllowed("GET", allow)
+	// the order is preserved, even when the caller reorders its own slice
+	allow[0], allow[1] = allow[1], allow[0]
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+	assert.Equal(t, []string{"PUT,POST"}, recorder.Header().Values("Allow"))
+	assert.Equal(t, `{"code":405,"message":"method GET is not allowed, but [PUT,POST] are"}`, recorder.Body.String())
+
+	jazon, jerr := json.Marshal(MethodNotAllowed("GET", []string{}))
+	require.NoError(t, jerr)
+	assert.Contains(t, string(jazon), `"allowed":[]`)
+
+	jazon, jerr = json.Marshal(MethodNotAllowed("GET", nil))
+	require.NoError(t, jerr)
+	assert.Contains(t, string(jazon), `"allowed":null`)
+}
+
+func TestErrorTransformer(t *testing.T) {
+	oldErrorTransformer, oldErrorLogger := ErrorTransformer, ErrorLogger
+	defer func() { ErrorTransformer, ErrorLogger = oldErrorTransformer, oldErrorLogger }()
+
+	var logged []error
+	ErrorLogger = func(_ *http.Request, err error) {
+		logged = append(logged, err)
+	}
+	ErrorTransformer = func(err error) error {
+		if HasCode(err, http.StatusNotFound) {
+			return New(http.StatusNotFound, "introuvable")
+		}
+		return err
+	}
+
+	original := NotFound("")
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, original)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, `{"code":404,"message":"introuvable"}`, recorder.Body.String())
+	assert.Equal(t, []error{original}, logged)
+
+	_, body := WouldServe(original)
+	assert.Equal(t, `{"code":404,"message":"introuvable"}`, string(body))
+
+	// untouched errors
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, New(http.StatusConflict, "conflict"))
+	assert.Equal(t, `{"code":409,"message":"conflict"}`, recorder.Body.String())
+
+	// a nil replacement keeps the original error
+	ErrorTransformer = func(error) error { return nil }
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, original)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+}
+
+func TestSkipContentTypeHeader(t *testing.T) {
+	oldSkipContentTypeHeader := SkipContentTypeHeader
+	defer func() { SkipContentTypeHeader = oldSkipContentTypeHeader }()
+	SkipContentTypeHeader = true
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Empty(t, recorder.Header().Values("Content-Type"))
+	assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	recorder.Header().Set("Content-Type", "application/vnd.api+json")
+	ServeError(recorder, nil, NotFound(""))
+	assert.Equal(t, []string{"application/vnd.api+json"}, recorder.Header().Values("Content-Type"))
+}
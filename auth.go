@@ -14,9 +14,97 @@
 
 package errors
 
-import "net/http"
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AuthenticationError is an authentication or authorization error carrying a WWW-Authenticate challenge
+type AuthenticationError struct {
+	code      int32
+	message   string
+	Challenge string
+}
+
+func (a *AuthenticationError) Error() string {
+	return errorMessage(a.message)
+}
+
+// Code the error code
+func (a *AuthenticationError) Code() int32 {
+	return a.code
+}
+
+// Headers returns the WWW-Authenticate challenge, if any
+func (a *AuthenticationError) Headers() http.Header {
+	if a.Challenge == "" {
+		return nil
+	}
+	return http.Header{"WWW-Authenticate": []string{a.Challenge}}
+}
+
+// MarshalJSON implements the JSON encoding interface
+func (a AuthenticationError) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(a.code),
+		MessageFieldName: jsonMessage(a.message),
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&a)
+	}
+	return json.Marshal(m)
+}
 
 // Unauthenticated returns an unauthenticated error
 func Unauthenticated(scheme string) Error {
 	return New(http.StatusUnauthorized, "unauthenticated for %s", scheme)
 }
+
+// UnauthenticatedWithRealm returns an unauthenticated error, with a challenge for the scheme and realm
+func UnauthenticatedWithRealm(scheme, realm string) Error {
+	challengeScheme := scheme
+	if challengeScheme != "" {
+		challengeScheme = strings.ToUpper(challengeScheme[:1]) + challengeScheme[1:]
+	}
+	return &AuthenticationError{
+		code:      http.StatusUnauthorized,
+		message:   fmt.Sprintf("unauthenticated for %s", scheme),
+		Challenge: fmt.Sprintf("%s realm=%q", challengeScheme, realm),
+	}
+}
+
+// InvalidToken returns an OAuth2 invalid_token error, with a Bearer challenge
+func InvalidToken(desc string) Error {
+	msg := "invalid token"
+	challenge := `Bearer error="invalid_token"`
+	if desc != "" {
+		msg += ": " + desc
+		challenge += fmt.Sprintf(", error_description=%q", desc)
+	}
+	return &AuthenticationError{
+		code:      http.StatusUnauthorized,
+		message:   msg,
+		Challenge: challenge,
+	}
+}
+
+// InsufficientScope returns an OAuth2 insufficient_scope error, with a Bearer challenge
+func InsufficientScope(required []string) Error {
+	scope := strings.Join(required, " ")
+	return &AuthenticationError{
+		code:      http.StatusForbidden,
+		message:   fmt.Sprintf("insufficient scope, requires [%s]", scope),
+		Challenge: fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope),
+	}
+}
+
+// TokenExpired returns an error for an expired or invalid one-time token of some kind (e.g. "csrf", "nonce")
+func TokenExpired(kind string) Error {
+	return &apiError{
+		code:    TokenExpiredCode,
+		status:  http.StatusForbidden,
+		message: fmt.Sprintf("the %s token has expired or is invalid", kind),
+	}
+}
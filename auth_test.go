@@ -15,6 +15,8 @@
 package errors
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -24,4 +26,49 @@ func TestUnauthenticated(t *testing.T) {
 	err := Unauthenticated("basic")
 	assert.EqualValues(t, 401, err.Code())
 	assert.Equal(t, "unauthenticated for basic", err.Error())
+
+	err = UnauthenticatedWithRealm("basic", "api")
+	assert.EqualValues(t, 401, err.Code())
+	assert.Equal(t, "unauthenticated for basic", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Basic realm="api"`, recorder.Header().Get("WWW-Authenticate"))
+}
+
+func TestOAuth2Errors(t *testing.T) {
+	err := InvalidToken("token expired")
+	assert.EqualValues(t, http.StatusUnauthorized, err.Code())
+	assert.Equal(t, "invalid token: token expired", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, `Bearer error="invalid_token", error_description="token expired"`, recorder.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, `{"code":401,"message":"invalid token: token expired"}`, recorder.Body.String())
+
+	err = InvalidToken("")
+	assert.Equal(t, "invalid token", err.Error())
+	assert.Equal(t, `Bearer error="invalid_token"`, err.(*AuthenticationError).Challenge)
+
+	err = InsufficientScope([]string{"read", "write"})
+	assert.EqualValues(t, http.StatusForbidden, err.Code())
+	assert.Equal(t, "insufficient scope, requires [read write]", err.Error())
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, `Bearer error="insufficient_scope", scope="read write"`, recorder.Header().Get("WWW-Authenticate"))
+}
+
+func TestTokenExpired(t *testing.T) {
+	err := TokenExpired("csrf")
+	assert.EqualValues(t, TokenExpiredCode, err.Code())
+	assert.Equal(t, "the csrf token has expired or is invalid", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, `{"code":620,"message":"the csrf token has expired or is invalid"}`, recorder.Body.String())
 }
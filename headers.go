@@ -18,8 +18,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 )
 
+// Severity qualifies how a validation failure should be handled
+type Severity int
+
+const (
+	// SeverityError is the default severity: the validation failure is an error
+	SeverityError Severity = iota
+	// SeverityWarning marks a validation failure which should not fail the request
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "error"
+	}
+}
+
 // Validation represents a failure of a precondition
 type Validation struct {
 	code    int32
@@ -28,10 +48,31 @@ type Validation struct {
 	Value   interface{}
 	message string
 	Values  []interface{}
+	// Source gives a finer provenance than In, e.g. the exact header,
+	// cookie or multipart part the value was read from
+	Source string
+	// Severity defaults to SeverityError
+	Severity Severity
+	// Indices are the positions of the offending items in an array, e.g. duplicates
+	Indices []int
+	// ExpectedType and ActualType describe a type mismatch, e.g. "integer" and "string"
+	ExpectedType string
+	ActualType   string
+	// SchemaID locates the schema which rejected the value, e.g. its $id or a JSON pointer
+	SchemaID string
+	// Hint describes the expected value, e.g. "expected YYYY-MM-DD"
+	Hint string
+	// Comparator and Limit describe the bound which failed validation, e.g. "<=" and 5
+	Comparator string
+	Limit      interface{}
+	keyword    string
+	// status is an explicit HTTP status, used instead of the code when set
+	status int
+	causes []error
 }
 
 func (e *Validation) Error() string {
-	return e.message
+	return errorMessage(e.message)
 }
 
 // Code the error code
@@ -39,21 +80,135 @@ func (e *Validation) Code() int32 {
 	return e.code
 }
 
+// FieldName returns the name of the validated field
+func (e *Validation) FieldName() string {
+	return e.Name
+}
+
+// Location returns where the validated field was found, e.g. "query"
+func (e *Validation) Location() string {
+	return e.In
+}
+
+// HTTPStatus returns the HTTP status this validation failure is served with
+func (e *Validation) HTTPStatus() int {
+	if e.status > 0 {
+		return e.status
+	}
+	return asHTTPCode(int(e.code))
+}
+
 // MarshalJSON implements the JSON encoding interface
 func (e Validation) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"code":    e.code,
-		"message": e.message,
-		"in":      e.In,
-		"name":    e.Name,
-		"value":   e.Value,
-		"values":  e.Values,
-	})
-}
-
-// ValidateName sets the name for a validation or updates it for a nested property
-func (e *Validation) ValidateName(name string) *Validation {
-	if name != "" {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(e.code),
+		MessageFieldName: jsonMessage(e.message),
+		"in":             e.In,
+		"name":           e.Name,
+		"values":         e.Values,
+	}
+	if e.Value != nil {
+		m["value"] = e.Value
+	}
+	if e.Source != "" {
+		m["source"] = e.Source
+	}
+	if e.Severity != SeverityError {
+		m["severity"] = e.Severity.String()
+	}
+	if keyword := e.Keyword(); keyword != "" {
+		m["keyword"] = keyword
+	}
+	if len(e.Indices) > 0 {
+		m["indices"] = e.Indices
+	}
+	if e.ExpectedType != "" {
+		m["expectedType"] = e.ExpectedType
+	}
+	if e.ActualType != "" {
+		m["actualType"] = e.ActualType
+	}
+	if e.Hint != "" {
+		m["hint"] = e.Hint
+	}
+	if e.SchemaID != "" {
+		m["schema"] = e.SchemaID
+	}
+	if len(e.causes) > 0 {
+		reasons := make([]string, 0, len(e.causes))
+		for _, cause := range e.causes {
+			reasons = append(reasons, cause.Error())
+		}
+		m["reasons"] = reasons
+	}
+	if e.Comparator != "" {
+		m["comparator"] = e.Comparator
+		m["limit"] = e.Limit
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&e)
+	}
+	return json.Marshal(m)
+}
+
+// Keyword returns the JSON schema keyword which failed validation, if any
+func (e *Validation) Keyword() string {
+	if e.keyword != "" {
+		return e.keyword
+	}
+	return CodeKinds[e.code]
+}
+
+// WithSource sets the detailed source of the validated value
+func (e *Validation) WithSource(source string) *Validation {
+	e.Source = source
+	return e
+}
+
+// WithHint sets a hint describing the expected value
+func (e *Validation) WithHint(hint string) *Validation {
+	e.Hint = hint
+	return e
+}
+
+// WithSchemaID sets the location of the schema which rejected the value
+func (e *Validation) WithSchemaID(schemaID string) *Validation {
+	e.SchemaID = schemaID
+	return e
+}
+
+// WithCauses sets the reasons why this validation failed, e.g. when a field fails for several reasons at once
+func (e *Validation) WithCauses(causes ...error) *Validation {
+	e.causes = make([]error, 0, len(causes))
+	for _, cause := range causes {
+		if cause != nil {
+			e.causes = append(e.causes, cause)
+		}
+	}
+	return e
+}
+
+// Unwrap returns the causes of this validation failure, if any
+func (e *Validation) Unwrap() []error {
+	return e.causes
+}
+
+// AsWarning downgrades this validation failure to a warning
+func (e *Validation) AsWarning() *Validation {
+	e.Severity = SeverityWarning
+	return e
+}
+
+// IsWarning tells if this validation failure is a warning
+func (e *Validation) IsWarning() bool {
+	return e.Severity == SeverityWarning
+}
+
+// ValidateName sets the name for a validation or updates it for a nested property.
+//
+// Several segments may be passed to denote a nested path: they are joined with ".", skipping empty segments.
+func (e *Validation) ValidateName(segments ...string) *Validation {
+	if name := joinSegments(segments); name != "" {
 		if e.Name == "" {
 			e.Name = name
 			e.message = name + e.message
@@ -65,9 +220,21 @@ func (e *Validation) ValidateName(name string) *Validation {
 	return e
 }
 
+func joinSegments(segments []string) string {
+	nonEmpty := make([]string, 0, len(segments))
+	for _, s := range segments {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	return strings.Join(nonEmpty, ".")
+}
+
 const (
 	contentTypeFail    = `unsupported media type %q, only %v are allowed`
 	responseFormatFail = `unsupported media type requested, only %v are available`
+	charsetFail        = `unsupported charset requested '%s', only %v are available`
+	missingHeaderFail  = `missing required header '%s'`
 )
 
 // InvalidContentType error for an invalid content type
@@ -101,3 +268,29 @@ func InvalidResponseFormat(value string, allowed []string) *Validation {
 		message: fmt.Sprintf(responseFormatFail, allowed),
 	}
 }
+
+// InvalidCharset error for an unacceptable charset request
+func InvalidCharset(requested string, available []string) Error {
+	values := make([]interface{}, 0, len(available))
+	for _, v := range available {
+		values = append(values, v)
+	}
+	return &Validation{
+		code:    http.StatusNotAcceptable,
+		Name:    "Accept-Charset",
+		In:      "header",
+		Value:   requested,
+		Values:  values,
+		message: fmt.Sprintf(charsetFail, requested, available),
+	}
+}
+
+// MissingHeader error for a required request header which is not present
+func MissingHeader(name string) Error {
+	return &Validation{
+		code:    http.StatusBadRequest,
+		Name:    name,
+		In:      "header",
+		message: fmt.Sprintf(missingHeaderFail, name),
+	}
+}
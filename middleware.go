@@ -16,7 +16,9 @@ package errors
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
 )
 
@@ -28,6 +30,16 @@ type APIVerificationFailed struct {
 	MissingRegistration  []string `json:"missingRegistration,omitempty"`
 }
 
+// Code the error code
+func (v *APIVerificationFailed) Code() int32 {
+	return APIVerificationFailedCode
+}
+
+// HTTPStatus returns the HTTP status this error is served with
+func (v *APIVerificationFailed) HTTPStatus() int {
+	return http.StatusInternalServerError
+}
+
 func (v *APIVerificationFailed) Error() string {
 	buf := bytes.NewBuffer(nil)
 
@@ -48,3 +60,18 @@ func (v *APIVerificationFailed) Error() string {
 
 	return buf.String()
 }
+
+// MarshalJSON implements the JSON encoding interface
+func (v APIVerificationFailed) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		CodeFieldName:          jsonCode(v.Code()),
+		MessageFieldName:       truncateMessage(v.Error()),
+		"section":              v.Section,
+		"missingSpecification": v.MissingSpecification,
+		"missingRegistration":  v.MissingRegistration,
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&v)
+	}
+	return json.Marshal(m)
+}
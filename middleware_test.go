@@ -15,9 +15,12 @@
 package errors
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAPIVerificationFailed(t *testing.T) {
@@ -31,3 +34,25 @@ func TestAPIVerificationFailed(t *testing.T) {
 missing from spec file [application/json, application/x-yaml] consumer`
 	assert.Equal(t, expected, err.Error())
 }
+
+func TestAPIVerificationFailedJSON(t *testing.T) {
+	err := &APIVerificationFailed{
+		Section:              "consumer",
+		MissingSpecification: []string{"application/json"},
+		MissingRegistration:  []string{"text/html"},
+	}
+	const expectedJSON = `{"code":621,"message":"missing [text/html] consumer registrations\nmissing from spec file [application/json] consumer",` +
+		`"section":"consumer","missingSpecification":["application/json"],"missingRegistration":["text/html"]}`
+
+	var apiErr Error = err
+	assert.EqualValues(t, APIVerificationFailedCode, apiErr.Code())
+
+	jazon, jerr := err.MarshalJSON()
+	require.NoError(t, jerr)
+	assert.JSONEq(t, expectedJSON, string(jazon))
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	assert.JSONEq(t, expectedJSON, recorder.Body.String())
+}
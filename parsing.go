@@ -17,6 +17,7 @@ package errors
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 )
 
 // ParseError represents a parsing error
@@ -30,7 +31,7 @@ type ParseError struct {
 }
 
 func (e *ParseError) Error() string {
-	return e.message
+	return errorMessage(e.message)
 }
 
 // Code returns the http status code for this error
@@ -38,20 +39,39 @@ func (e *ParseError) Code() int32 {
 	return e.code
 }
 
+// FieldName returns the name of the parsed field
+func (e *ParseError) FieldName() string {
+	return e.Name
+}
+
+// Location returns where the parsed field was found, e.g. "query"
+func (e *ParseError) Location() string {
+	return e.In
+}
+
+// Unwrap returns the reason of this parse error
+func (e *ParseError) Unwrap() error {
+	return e.Reason
+}
+
 // MarshalJSON implements the JSON encoding interface
 func (e ParseError) MarshalJSON() ([]byte, error) {
 	var reason string
 	if e.Reason != nil {
 		reason = e.Reason.Error()
 	}
-	return json.Marshal(map[string]interface{}{
-		"code":    e.code,
-		"message": e.message,
-		"in":      e.In,
-		"name":    e.Name,
-		"value":   e.Value,
-		"reason":  reason,
-	})
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(e.code),
+		MessageFieldName: jsonMessage(e.message),
+		"in":             e.In,
+		"name":           e.Name,
+		"value":          e.Value,
+		"reason":         reason,
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&e)
+	}
+	return json.Marshal(m)
 }
 
 const (
@@ -76,3 +96,54 @@ func NewParseError(name, in, value string, reason error) *ParseError {
 		message: msg,
 	}
 }
+
+// InvalidJSONError represents a request body which is not valid JSON
+type InvalidJSONError struct {
+	code    int32
+	Reason  error
+	message string
+}
+
+func (e *InvalidJSONError) Error() string {
+	return errorMessage(e.message)
+}
+
+// Code returns the http status code for this error
+func (e *InvalidJSONError) Code() int32 {
+	return e.code
+}
+
+// Unwrap returns the decoding error, e.g. a *json.SyntaxError
+func (e *InvalidJSONError) Unwrap() error {
+	return e.Reason
+}
+
+// MarshalJSON implements the JSON encoding interface
+func (e InvalidJSONError) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(e.code),
+		MessageFieldName: jsonMessage(e.message),
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&e)
+	}
+	return json.Marshal(m)
+}
+
+// InvalidJSON creates a new error for a request body which is not valid JSON
+func InvalidJSON(reason error) Error {
+	message := "request body is not valid JSON"
+	if reason != nil {
+		message += ": " + reason.Error()
+	}
+	return &InvalidJSONError{
+		code:    http.StatusBadRequest,
+		Reason:  reason,
+		message: message,
+	}
+}
+
+// MaxNestingExceeded creates a new error for a request JSON body nested deeper than the maximum depth
+func MaxNestingExceeded(max int) Error {
+	return NewWithStatus(MaxNestingExceededCode, http.StatusBadRequest, "request JSON nesting exceeds maximum depth %d", max)
+}
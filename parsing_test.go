@@ -15,10 +15,16 @@
 package errors
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseError(t *testing.T) {
@@ -30,3 +36,92 @@ func TestParseError(t *testing.T) {
 	assert.EqualValues(t, 400, err.Code())
 	assert.Equal(t, "parsing Content-Type from \"application(\" failed, because unable to parse", err.Error())
 }
+
+func TestParseErrorUnwrap(t *testing.T) {
+	sentinel := errors.New("unable to parse")
+	parseErr := NewParseError("Content-Type", "header", "application(", sentinel)
+	require.ErrorIs(t, parseErr, sentinel)
+
+	composite := CompositeValidationError(
+		Required("name", "query", nil),
+		CompositeValidationError(parseErr),
+	)
+	require.ErrorIs(t, composite, sentinel)
+
+	var target *ParseError
+	require.ErrorAs(t, composite, &target)
+	assert.Equal(t, parseErr, target)
+}
+
+func TestInvalidJSON(t *testing.T) {
+	var v interface{}
+	reason := json.Unmarshal([]byte(`{"a":`), &v)
+	var syntaxErr *json.SyntaxError
+	require.ErrorAs(t, reason, &syntaxErr)
+
+	err := InvalidJSON(reason)
+	assert.EqualValues(t, http.StatusBadRequest, err.Code())
+	assert.Equal(t, "request body is not valid JSON: unexpected end of JSON input", err.Error())
+	require.ErrorIs(t, err, reason)
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, `{"code":400,"message":"request body is not valid JSON: unexpected end of JSON input"}`, recorder.Body.String())
+
+	// raw syntax errors are served as invalid JSON as well
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, reason)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t, `{"code":400,"message":"request body is not valid JSON: unexpected end of JSON input"}`, recorder.Body.String())
+}
+
+func TestInvalidJSONDecodingErrors(t *testing.T) {
+	assert.Equal(t, "request body is not valid JSON", InvalidJSON(nil).Error())
+
+	var v struct {
+		A int `json:"a"`
+	}
+	syntaxErr := json.Unmarshal([]byte(`{"a":}`), &v)
+	wrappedSyntaxErr := fmt.Errorf("decode: %w", syntaxErr)
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, wrappedSyntaxErr)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t,
+		fmt.Sprintf(`{"code":400,"message":"request body is not valid JSON: %s"}`, wrappedSyntaxErr.Error()),
+		recorder.Body.String(),
+	)
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, fmt.Errorf("handler: %w", InvalidJSON(syntaxErr)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t,
+		fmt.Sprintf(`{"code":400,"message":"request body is not valid JSON: %s"}`, syntaxErr.Error()),
+		recorder.Body.String(),
+	)
+
+	typeErr := json.Unmarshal([]byte(`{"a":"x"}`), &v)
+	for _, reason := range []error{
+		typeErr,
+		fmt.Errorf("decode: %w", typeErr),
+		fmt.Errorf("reading upload: %w", io.ErrUnexpectedEOF),
+	} {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, nil, reason)
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	}
+}
+
+func TestMaxNestingExceeded(t *testing.T) {
+	err := MaxNestingExceeded(32)
+	assert.EqualValues(t, MaxNestingExceededCode, err.Code())
+	assert.Equal(t, "request JSON nesting exceeds maximum depth 32", err.Error())
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Equal(t,
+		fmt.Sprintf(`{"code":%d,"message":"request JSON nesting exceeds maximum depth 32"}`, MaxNestingExceededCode),
+		recorder.Body.String(),
+	)
+}
@@ -0,0 +1,230 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NegotiateContentType makes ServeError pick the renderer of the error body from the Accept header of the request.
+//
+// When disabled (the default) or when no registered renderer is acceptable, errors are served as JSON.
+var NegotiateContentType bool
+
+// Renderer renders an error served with some HTTP status as the body of a response
+type Renderer func(r *http.Request, status int, err Error) []byte
+
+// JSONRenderer replaces the default JSON body of error responses, when set.
+//
+// For example, SpringStyleRenderer produces the error shape of Spring Boot applications.
+var JSONRenderer Renderer
+
+// now is overridden in tests
+var now = time.Now
+
+// SpringStyleRenderer renders an error with the shape of Spring Boot applications:
+//
+//	{"timestamp":"...","status":404,"error":"Not Found","message":"...","path":"/..."}
+func SpringStyleRenderer(r *http.Request, status int, err Error) []byte {
+	var path string
+	if r != nil && r.URL != nil {
+		path = r.URL.Path
+	}
+	//nolint:errchkjson
+	b, _ := json.Marshal(struct {
+		Timestamp string `json:"timestamp"`
+		Status    int    `json:"status"`
+		Error     string `json:"error"`
+		Message   string `json:"message"`
+		Path      string `json:"path"`
+	}{
+		Timestamp: now().UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		Status:    status,
+		Error:     http.StatusText(status),
+		Message:   truncateMessage(err.Error()),
+		Path:      path,
+	})
+	return b
+}
+
+type registeredRenderer struct {
+	contentType string
+	render      Renderer
+}
+
+// renderers are keyed by media type
+var renderers = map[string]registeredRenderer{
+	"text/plain":               {contentType: "text/plain; charset=utf-8", render: renderPlainText},
+	"application/problem+json": {contentType: "application/problem+json", render: ProblemRenderer},
+}
+
+// ProblemTypeBaseURI is the base of the "type" URI of RFC 7807 problem details, completed by the kind of the error, e.g.
+// "https://errors.example.com/required".
+//
+// When empty, or when the kind of the error is unknown, the type is "about:blank".
+var ProblemTypeBaseURI string
+
+// InstanceIDGenerator produces the "instance" URI of RFC 7807 problem details, identifying an occurrence of an error,
+// e.g. "/errors/<uuid>".
+//
+// When unset, or when it returns an empty string, the instance is omitted.
+var InstanceIDGenerator func(r *http.Request) string
+
+// RegisterRenderer registers a renderer for a media type, served with the given Content-Type header.
+//
+// Renderers should be registered at initialization time: the registry is not safe for concurrent use with ServeError.
+func RegisterRenderer(mediaType, contentType string, renderer Renderer) {
+	renderers[strings.ToLower(mediaType)] = registeredRenderer{
+		contentType: contentType,
+		render:      renderer,
+	}
+}
+
+// ProblemRenderer renders an error as RFC 7807 problem details, with the error code as an extension member
+func ProblemRenderer(r *http.Request, status int, err Error) []byte {
+	var instance string
+	if InstanceIDGenerator != nil && r != nil {
+		instance = InstanceIDGenerator(r)
+	}
+	//nolint:errchkjson
+	b, _ := json.Marshal(struct {
+		Type     string      `json:"type"`
+		Title    string      `json:"title"`
+		Status   int         `json:"status"`
+		Detail   string      `json:"detail"`
+		Instance string      `json:"instance,omitempty"`
+		Code     interface{} `json:"code"`
+	}{
+		Type:     problemType(err),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   truncateMessage(err.Error()),
+		Instance: instance,
+		Code:     jsonCode(err.Code()),
+	})
+	return b
+}
+
+func problemType(err Error) string {
+	kind := errorKind(err)
+	if ProblemTypeBaseURI == "" || kind == "" {
+		return "about:blank"
+	}
+	return strings.TrimSuffix(ProblemTypeBaseURI, "/") + "/" + kind
+}
+
+// errorKind returns the JSON schema keyword of validation errors, or the kind registered in CodeKinds
+func errorKind(err Error) string {
+	if ve, ok := err.(*Validation); ok {
+		return ve.Keyword()
+	}
+	return CodeKinds[err.Code()]
+}
+
+func renderPlainText(_ *http.Request, _ int, err Error) []byte {
+	return []byte(truncateMessage(err.Error()))
+}
+
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// addVary tells caches that the response depends on the Accept header of the request, when content is negotiated
+func addVary(header http.Header, r *http.Request) {
+	if !NegotiateContentType || r == nil {
+		return
+	}
+	for _, v := range header.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if f := strings.TrimSpace(field); f == "*" || strings.EqualFold(f, "Accept") {
+				return
+			}
+		}
+	}
+	header.Add("Vary", "Accept")
+}
+
+// negotiateRenderer returns the registered renderer preferred by the Accept header of the request.
+//
+// It returns false when JSON should be served.
+func negotiateRenderer(r *http.Request) (registeredRenderer, bool) {
+	if !NegotiateContentType || r == nil {
+		return registeredRenderer{}, false
+	}
+
+	for _, accepted := range parseAccept(r.Header.Get("Accept")) {
+		switch accepted.mediaType {
+		case "application/json", "application/*", "*/*":
+			return registeredRenderer{}, false
+		}
+		if rr, ok := renderers[accepted.mediaType]; ok {
+			return rr, true
+		}
+		if prefix, isRange := strings.CutSuffix(accepted.mediaType, "/*"); isRange {
+			if rr, ok := matchRenderer(prefix + "/"); ok {
+				return rr, true
+			}
+		}
+	}
+
+	return registeredRenderer{}, false
+}
+
+// matchRenderer finds a registered renderer by media type prefix, in a deterministic order
+func matchRenderer(prefix string) (registeredRenderer, bool) {
+	mediaTypes := make([]string, 0, len(renderers))
+	for mediaType := range renderers {
+		if strings.HasPrefix(mediaType, prefix) {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	if len(mediaTypes) == 0 {
+		return registeredRenderer{}, false
+	}
+	sort.Strings(mediaTypes)
+	return renderers[mediaTypes[0]], true
+}
+
+// parseAccept parses an Accept header into media types ordered by decreasing quality
+func parseAccept(header string) []acceptedMediaType {
+	var res []acceptedMediaType
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		res = append(res, acceptedMediaType{mediaType: mediaType, quality: quality})
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].quality > res[j].quality
+	})
+	return res
+}
@@ -0,0 +1,226 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeErrorNegotiation(t *testing.T) {
+	request := func(accept string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", accept)
+		return r
+	}
+
+	t.Run("negotiation is disabled by default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("text/plain"), NotFound(""))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+	})
+
+	oldNegotiateContentType := NegotiateContentType
+	defer func() { NegotiateContentType = oldNegotiateContentType }()
+	NegotiateContentType = true
+
+	t.Run("should serve plain text", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("text/plain"), NotFound(""))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "Not found", recorder.Body.String())
+		assert.Equal(t, "9", recorder.Header().Get("Content-Length"))
+	})
+
+	t.Run("should serve plain text for a media range", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("text/*"), NotFound(""))
+		assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "Not found", recorder.Body.String())
+	})
+
+	t.Run("should honor quality values", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("text/plain;q=0.5, application/json"), NotFound(""))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+	})
+
+	t.Run("should fall back to JSON", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("image/png"), NotFound(""))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `{"code":404,"message":"Not found"}`, recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		ServeError(recorder, nil, NotFound(""))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	})
+
+	t.Run("should use a registered renderer", func(t *testing.T) {
+		defer delete(renderers, "application/xml")
+		RegisterRenderer("application/xml", "application/xml", func(_ *http.Request, _ int, err Error) []byte {
+			return []byte("<error>" + err.Error() + "</error>")
+		})
+
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, request("application/xml"), NotFound(""))
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+		assert.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, "<error>Not found</error>", recorder.Body.String())
+	})
+}
+
+func TestSpringStyleRenderer(t *testing.T) {
+	oldNow := now
+	oldJSONRenderer := JSONRenderer
+	defer func() {
+		now = oldNow
+		JSONRenderer = oldJSONRenderer
+	}()
+	now = func() time.Time {
+		return time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	}
+	JSONRenderer = SpringStyleRenderer
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, httptest.NewRequest(http.MethodGet, "/pets/1?x=y", nil), NotFound("no pet"))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`{"timestamp":"2024-01-02T03:04:05.006Z","status":404,"error":"Not Found","message":"no pet","path":"/pets/1"}`,
+		recorder.Body.String(),
+	)
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, InvalidTypeName("x"))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t,
+		`{"timestamp":"2024-01-02T03:04:05.006Z","status":422,"error":"Unprocessable Entity","message":"x is an invalid type name","path":""}`,
+		recorder.Body.String(),
+	)
+}
+
+func TestProblemRenderer(t *testing.T) {
+	oldNegotiateContentType := NegotiateContentType
+	defer func() { NegotiateContentType = oldNegotiateContentType }()
+	NegotiateContentType = true
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/problem+json")
+
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, r, Required("name", "query", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, "application/problem+json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t,
+		`{"type":"about:blank","title":"Unprocessable Entity","status":422,"detail":"name in query is required","code":602}`,
+		recorder.Body.String(),
+	)
+
+	oldProblemTypeBaseURI := ProblemTypeBaseURI
+	defer func() { ProblemTypeBaseURI = oldProblemTypeBaseURI }()
+	ProblemTypeBaseURI = "https://errors.example.com/"
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, Required("name", "query", nil))
+	assert.Equal(t,
+		`{"type":"https://errors.example.com/required","title":"Unprocessable Entity","status":422,"detail":"name in query is required","code":602}`,
+		recorder.Body.String(),
+	)
+
+	// no known kind
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Equal(t,
+		`{"type":"about:blank","title":"Not Found","status":404,"detail":"Not found","code":404}`,
+		recorder.Body.String(),
+	)
+
+	oldInstanceIDGenerator := InstanceIDGenerator
+	defer func() { InstanceIDGenerator = oldInstanceIDGenerator }()
+	InstanceIDGenerator = func(r *http.Request) string {
+		return "/errors/" + r.Header.Get("X-Request-Id")
+	}
+	r.Header.Set("X-Request-Id", "8a6e0804")
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t,
+		`{"type":"about:blank","title":"Not Found","status":404,"detail":"Not found","instance":"/errors/8a6e0804","code":404}`,
+		recorder.Body.String(),
+	)
+}
+
+func TestServeErrorVary(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+
+	// no negotiation
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Empty(t, recorder.Header().Values("Vary"))
+
+	oldNegotiateContentType := NegotiateContentType
+	defer func() { NegotiateContentType = oldNegotiateContentType }()
+	NegotiateContentType = true
+
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, []string{"Accept"}, recorder.Header().Values("Vary"))
+
+	// JSON chosen after negotiation
+	r.Header.Set("Accept", "application/json")
+	recorder = httptest.NewRecorder()
+	recorder.Header().Set("Vary", "Origin")
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, []string{"Origin", "Accept"}, recorder.Header().Values("Vary"))
+
+	// not repeated
+	recorder = httptest.NewRecorder()
+	recorder.Header().Set("Vary", "Origin, accept")
+	ServeError(recorder, r, NotFound(""))
+	assert.Equal(t, []string{"Origin, accept"}, recorder.Header().Values("Vary"))
+}
+
+func TestRenderersMaxMessageLength(t *testing.T) {
+	oldNegotiateContentType, oldMaxMessageLength := NegotiateContentType, MaxMessageLength
+	defer func() { NegotiateContentType, MaxMessageLength = oldNegotiateContentType, oldMaxMessageLength }()
+	NegotiateContentType = true
+	MaxMessageLength = 5
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, r, NotFound("resource not found"))
+	assert.Equal(t, "resou...", recorder.Body.String())
+
+	r.Header.Set("Accept", "application/problem+json")
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, NotFound("resource not found"))
+	assert.Contains(t, recorder.Body.String(), `"detail":"resou..."`)
+
+	body := SpringStyleRenderer(r, http.StatusNotFound, NotFound("resource not found"))
+	assert.Contains(t, string(body), `"message":"resou..."`)
+}
@@ -17,7 +17,10 @@ package errors
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"strings"
+	"time"
 )
 
 const (
@@ -26,11 +29,18 @@ const (
 	typeFailWithData          = "%s in %s must be of type %s: %q"
 	typeFailWithError         = "%s in %s must be of type %s, because: %s"
 	requiredFail              = "%s in %s is required"
+	emptyNotAllowed           = "%s in %s may not be empty"
 	readOnlyFail              = "%s in %s is readOnly"
+	readOnlyModified          = "%s in %s is readOnly and cannot be modified to %v"
+	writeOnlyFail             = "%s in %s is writeOnly"
 	tooLongMessage            = "%s in %s should be at most %d chars long"
 	tooShortMessage           = "%s in %s should be at least %d chars long"
+	fileTooLarge              = "%s in %s should be at most %s"
+	fileTooSmall              = "%s in %s should be at least %s"
 	patternFail               = "%s in %s should match '%s'"
+	patternsFail              = "%s in %s should match one of [%s]"
 	enumFail                  = "%s in %s should be one of %v"
+	forbiddenFail             = "%s in %s must not be one of %v"
 	multipleOfFail            = "%s in %s should be a multiple of %v"
 	maxIncFail                = "%s in %s should be less than or equal to %v"
 	maxExcFail                = "%s in %s should be less than %v"
@@ -43,11 +53,18 @@ const (
 	typeFailWithDataNoIn      = "%s must be of type %s: %q"
 	typeFailWithErrorNoIn     = "%s must be of type %s, because: %s"
 	requiredFailNoIn          = "%s is required"
+	emptyNotAllowedNoIn       = "%s may not be empty"
 	readOnlyFailNoIn          = "%s is readOnly"
+	readOnlyModifiedNoIn      = "%s is readOnly and cannot be modified to %v"
+	writeOnlyFailNoIn         = "%s is writeOnly"
 	tooLongMessageNoIn        = "%s should be at most %d chars long"
 	tooShortMessageNoIn       = "%s should be at least %d chars long"
+	fileTooLargeNoIn          = "%s should be at most %s"
+	fileTooSmallNoIn          = "%s should be at least %s"
 	patternFailNoIn           = "%s should match '%s'"
+	patternsFailNoIn          = "%s should match one of [%s]"
 	enumFailNoIn              = "%s should be one of %v"
+	forbiddenFailNoIn         = "%s must not be one of %v"
 	multipleOfFailNoIn        = "%s should be a multiple of %v"
 	maxIncFailNoIn            = "%s should be less than or equal to %v"
 	maxExcFailNoIn            = "%s should be less than %v"
@@ -67,8 +84,47 @@ const (
 	failedAllPatternProps     = "%s.%s in %s failed all pattern properties"
 	failedAllPatternPropsNoIn = "%s.%s failed all pattern properties"
 	multipleOfMustBePositive  = "factor MultipleOf declared for %s must be positive: %v"
+	recursionLimitExceeded    = "validation recursion limit exceeded at %s (depth %d)"
+	maxIncFailLexical         = "%s in %s should be lexically less than or equal to '%s'"
+	maxExcFailLexical         = "%s in %s should be lexically less than '%s'"
+	minIncFailLexical         = "%s in %s should be lexically greater than or equal to '%s'"
+	minExcFailLexical         = "%s in %s should be lexically greater than '%s'"
+	maxIncFailLexicalNoIn     = "%s should be lexically less than or equal to '%s'"
+	maxExcFailLexicalNoIn     = "%s should be lexically less than '%s'"
+	minIncFailLexicalNoIn     = "%s should be lexically greater than or equal to '%s'"
+	minExcFailLexicalNoIn     = "%s should be lexically greater than '%s'"
+	typeFailWithFormatted     = "%s in %s must be of type %s: %s"
+	typeFailWithFormattedNoIn = "%s must be of type %s: %s"
+	pageSizeExceeded          = "%s in %s page size %d exceeds maximum %d"
+	pageSizeExceededNoIn      = "%s page size %d exceeds maximum %d"
+	unknownParam              = "unknown parameter '%s' in %s is not allowed"
+	unknownParamNoIn          = "unknown parameter '%s' is not allowed"
+	uniqueFailAt              = "%s in %s contains duplicates at positions %v"
+	uniqueFailAtNoIn          = "%s contains duplicates at positions %v"
+	mutuallyExclusive         = "only one of %v may be provided in %s"
+	mutuallyExclusiveNoIn     = "only one of %v may be provided"
+	atLeastOneRequired        = "at least one of %v is required in %s"
+	atLeastOneRequiredNoIn    = "at least one of %v is required"
+	mustBeNull                = "%s in %s must be null"
+	mustBeNullNoIn            = "%s must be null"
+	typeFailActual            = "%s in %s must be of type %s, got %s"
+	typeFailActualNoIn        = "%s must be of type %s, got %s"
+	decodedTooLong            = "%s in %s should be at most %d bytes long once decoded"
+	decodedTooLongNoIn        = "%s should be at most %d bytes long once decoded"
+	decodedTooShort           = "%s in %s should be at least %d bytes long once decoded"
+	decodedTooShortNoIn       = "%s should be at least %d bytes long once decoded"
+	durationTooLong           = "%s in %s should be a duration of at most %s"
+	durationTooLongNoIn       = "%s should be a duration of at most %s"
+	durationTooShort          = "%s in %s should be a duration of at least %s"
+	durationTooShortNoIn      = "%s should be a duration of at least %s"
+	formatFail                = "%s must conform to format '%s'"
 )
 
+// ValueFormatter customizes how the offending value is rendered in the message of InvalidType.
+//
+// When nil, string values are quoted, errors are reported as the cause and other values are omitted.
+var ValueFormatter func(v interface{}) string
+
 // All code responses can be used to differentiate errors for different handling
 // by the consuming program
 const (
@@ -95,13 +151,109 @@ const (
 	FailedAllPatternPropsCode
 	MultipleOfMustBePositiveCode
 	ReadOnlyFailCode
+	// TokenExpiredCode is used for stale or invalid one-time tokens, served as 403
+	TokenExpiredCode
+	// APIVerificationFailedCode is used when api registrations and the api spec mismatch, served as 500
+	APIVerificationFailedCode
+	WriteOnlyFailCode
+	ForbiddenValueCode
+	// RecursionLimitExceededCode is used when validation recurses too deeply, e.g. in a circular schema, served as 400
+	RecursionLimitExceededCode
+	EmptyNotAllowedCode
+	// PageSizeExceededCode is used when a list request asks for more items than the maximum page size
+	PageSizeExceededCode
+	// UnknownParamCode is used when a request carries a parameter which is not declared, e.g. in strict APIs
+	UnknownParamCode
+	// MaxNestingExceededCode is used when a request JSON body is nested too deeply, served as 400
+	MaxNestingExceededCode
+	// MutuallyExclusiveCode is used when several parameters are provided, while only one of them may be
+	MutuallyExclusiveCode
+	// AtLeastOneRequiredCode is used when none of a group of parameters is provided, while at least one of them must be
+	AtLeastOneRequiredCode
+	// MustBeNullCode is used when a value is not null, while its schema only admits null
+	MustBeNullCode
+	// QuotaExceededCode is used when a client exhausted its quota of requests until some reset time, served as 429
+	QuotaExceededCode
+	// FormatFailCode is used when a value does not conform to its string format, e.g. email or uuid
+	FormatFailCode
 )
 
+// IsTypeError tells if an error, or any error it wraps or groups, is an invalid type error
+func IsTypeError(err error) bool {
+	return HasCode(err, InvalidTypeCode)
+}
+
+// FormatHints maps string formats to a hint describing the expected value, reported by InvalidType
+var FormatHints = map[string]string{
+	"date":      "expected YYYY-MM-DD",
+	"date-time": "expected RFC 3339, e.g. 2006-01-02T15:04:05Z",
+	"duration":  "expected a duration, e.g. 1h30m",
+	"email":     "expected an email address, e.g. user@example.com",
+	"ipv4":      "expected a dotted decimal IPv4 address, e.g. 192.0.2.1",
+	"ipv6":      "expected an IPv6 address, e.g. 2001:db8::1",
+	"uri":       "expected an absolute URI, e.g. https://example.com/path",
+	"uuid":      "expected 32 hexadecimal digits grouped as 8-4-4-4-12",
+}
+
+// formatMessages maps string formats to the template of the message reported by FormatFail
+var formatMessages = map[string]string{
+	"date":      "%s must be a valid date",
+	"date-time": "%s must be a valid date-time",
+	"email":     "%s must be a valid email address",
+	"ipv4":      "%s must be a valid IPv4 address",
+	"ipv6":      "%s must be a valid IPv6 address",
+	"uri":       "%s must be a valid URI",
+	"uuid":      "%s must be a valid UUID",
+}
+
+// RegisterFormatMessage registers the template of the message reported by FormatFail for a string format.
+//
+// The template is formatted with the field, e.g. "%s must be a valid email address" yields
+// "email in body must be a valid email address". Messages should be registered at initialization time:
+// the registry is not safe for concurrent use.
+func RegisterFormatMessage(format, template string) {
+	formatMessages[format] = template
+}
+
+// CodeKinds maps validation error codes to the JSON schema keyword they originate from
+var CodeKinds = map[int32]string{
+	InvalidTypeCode:              "type",
+	RequiredFailCode:             "required",
+	TooLongFailCode:              "maxLength",
+	TooShortFailCode:             "minLength",
+	PatternFailCode:              "pattern",
+	EnumFailCode:                 "enum",
+	MultipleOfFailCode:           "multipleOf",
+	MaxFailCode:                  "maximum",
+	MinFailCode:                  "minimum",
+	UniqueFailCode:               "uniqueItems",
+	MaxItemsFailCode:             "maxItems",
+	MinItemsFailCode:             "minItems",
+	NoAdditionalItemsCode:        "additionalItems",
+	TooFewPropertiesCode:         "minProperties",
+	TooManyPropertiesCode:        "maxProperties",
+	UnallowedPropertyCode:        "additionalProperties",
+	FailedAllPatternPropsCode:    "patternProperties",
+	MultipleOfMustBePositiveCode: "multipleOf",
+	ReadOnlyFailCode:             "readOnly",
+	WriteOnlyFailCode:            "writeOnly",
+	EmptyNotAllowedCode:          "allowEmptyValue",
+	MustBeNullCode:               "type",
+	FormatFailCode:               "format",
+}
+
 // CompositeError is an error that groups several errors together
 type CompositeError struct {
-	Errors  []error
+	Errors []error
+	// Detail is an optional human readable summary of the errors, serialized as "detail".
+	// It is omitted when CodeFieldName or MessageFieldName is "detail" as well.
+	Detail  string
 	code    int32
 	message string
+	// keepCode serves this composite with its own code rather than the code of its first child
+	keepCode bool
+	// status is an explicit HTTP status, used instead of the code when set
+	status int
 }
 
 // Code for this error
@@ -109,32 +261,216 @@ func (c *CompositeError) Code() int32 {
 	return c.code
 }
 
+// HTTPStatus returns the HTTP status this composite is served with, when it keeps its own code
+func (c *CompositeError) HTTPStatus() int {
+	if c.status > 0 {
+		return c.status
+	}
+	return asHTTPCode(int(c.code))
+}
+
 func (c *CompositeError) Error() string {
 	if len(c.Errors) > 0 {
-		msgs := []string{c.message + ":"}
+		msgs := []string{errorMessage(c.message) + ":"}
 		for _, e := range c.Errors {
 			msgs = append(msgs, e.Error())
 		}
 		return strings.Join(msgs, "\n")
 	}
-	return c.message
+	return errorMessage(c.message)
 }
 
+// Unwrap returns the errors of this composite, satisfying the standard multi-error interface used by errors.Is and errors.As.
+//
+// Since Errors is an exported field, this composite cannot expose an Errors() method as well.
 func (c *CompositeError) Unwrap() []error {
+	if c == nil {
+		return nil
+	}
 	return c.Errors
 }
 
+// Summary describes this composite on a single line, e.g. "422: 3 validation errors (required, type)"
+func (c *CompositeError) Summary() string {
+	if c == nil {
+		return ""
+	}
+	flat := flattenComposite(c)
+	noun := "validation errors"
+	if len(flat.Errors) == 1 {
+		noun = "validation error"
+	}
+	summary := fmt.Sprintf("%d: %d %s", c.code, len(flat.Errors), noun)
+
+	var kinds []string
+	seen := make(map[string]bool)
+	for _, e := range flat.Errors {
+		ae, ok := e.(Error)
+		if !ok {
+			continue
+		}
+		if kind := errorKind(ae); kind != "" && !seen[kind] {
+			seen[kind] = true
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) > 0 {
+		summary += " (" + strings.Join(kinds, ", ") + ")"
+	}
+	return summary
+}
+
+// First returns the first error in this composite, skipping empty nested composites, or nil if there is none
+func (c *CompositeError) First() error {
+	if c == nil {
+		return nil
+	}
+	flat := flattenComposite(c)
+	if len(flat.Errors) == 0 {
+		return nil
+	}
+	return flat.Errors[0]
+}
+
+// Last returns the last error in this composite, skipping empty nested composites, or nil if there is none
+func (c *CompositeError) Last() error {
+	if c == nil {
+		return nil
+	}
+	flat := flattenComposite(c)
+	if len(flat.Errors) == 0 {
+		return nil
+	}
+	return flat.Errors[len(flat.Errors)-1]
+}
+
+// ErrorOrNil returns this composite as an error, or an untyped nil when it holds no error, skipping empty nested composites
+func (c *CompositeError) ErrorOrNil() error {
+	if c.First() == nil {
+		return nil
+	}
+	return c
+}
+
+// SplitWarnings separates the errors in this composite from the validation failures with a warning severity.
+//
+// Nested composites are flattened.
+func (c *CompositeError) SplitWarnings() (errs, warnings []error) {
+	if c == nil {
+		return nil, nil
+	}
+	for _, e := range flattenComposite(c).Errors {
+		if ve, ok := e.(*Validation); ok && ve.IsWarning() {
+			warnings = append(warnings, e)
+			continue
+		}
+		errs = append(errs, e)
+	}
+	return errs, warnings
+}
+
+// Warnings returns all validation failures with a warning severity in this composite
+func (c *CompositeError) Warnings() []error {
+	_, warnings := c.SplitWarnings()
+	return warnings
+}
+
+// FieldErrors groups the messages of this composite by field name, recursing into nested composites.
+//
+// Messages of errors which are not bound to a named field are grouped under the empty key.
+func (c *CompositeError) FieldErrors() map[string][]string {
+	res := make(map[string][]string)
+	if c == nil {
+		return res
+	}
+	for _, e := range flattenComposite(c).Errors {
+		var name string
+		if ve, ok := e.(*Validation); ok {
+			name = ve.Name
+		}
+		res[name] = append(res[name], e.Error())
+	}
+	return res
+}
+
+// ToURLValues groups the messages of this composite by field name like FieldErrors, as url.Values.
+//
+// The messages are not escaped: use Encode to build a query string, e.g. for a redirect.
+func (c *CompositeError) ToURLValues() url.Values {
+	return url.Values(c.FieldErrors())
+}
+
+// ToFieldObject groups the messages of the validation errors in this composite as a nested object, e.g.:
+//
+//	{"fields":{"email":{"messages":["email in body is required"]}}}
+func (c *CompositeError) ToFieldObject() map[string]interface{} {
+	fields := make(map[string]interface{})
+	if c != nil {
+		messages := make(map[string][]string)
+		for _, e := range flattenComposite(c).Errors {
+			if ve, ok := e.(*Validation); ok {
+				messages[ve.Name] = append(messages[ve.Name], ve.Error())
+			}
+		}
+		for name, msgs := range messages {
+			fields[name] = map[string]interface{}{"messages": msgs}
+		}
+	}
+	return map[string]interface{}{"fields": fields}
+}
+
 // MarshalJSON implements the JSON encoding interface
 func (c CompositeError) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
-		"code":    c.code,
-		"message": c.message,
-		"errors":  c.Errors,
-	})
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(c.code),
+		MessageFieldName: jsonMessage(c.message),
+		"errors":         c.Errors,
+	}
+	if c.hasDetail() {
+		m["detail"] = c.Detail
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(&c)
+	}
+	return json.Marshal(m)
+}
+
+// MarshalJSONVerbose encodes this composite as JSON.
+//
+// When verbose is false, nested composites are flattened and the errors are rendered as a list of messages only.
+func (c *CompositeError) MarshalJSONVerbose(verbose bool) ([]byte, error) {
+	if verbose {
+		return c.MarshalJSON()
+	}
+	flat := flattenComposite(c)
+	msgs := make([]string, 0, len(flat.Errors))
+	for _, e := range flat.Errors {
+		msgs = append(msgs, e.Error())
+	}
+	m := map[string]interface{}{
+		CodeFieldName:    jsonCode(c.code),
+		MessageFieldName: jsonMessage(c.message),
+		"errors":         msgs,
+	}
+	if c.hasDetail() {
+		m["detail"] = c.Detail
+	}
+	if IncludeRetryable {
+		m["retryable"] = retryable(c)
+	}
+	return json.Marshal(m)
+}
+
+// hasDetail tells if the detail of this composite is serialized, without colliding with the code or the message
+func (c *CompositeError) hasDetail() bool {
+	return c.Detail != "" && CodeFieldName != "detail" && MessageFieldName != "detail"
 }
 
 // CompositeValidationError an error to wrap a bunch of other errors
 func CompositeValidationError(errors ...error) *CompositeError {
+	if AutoFlattenComposite {
+		errors = inlineComposites(errors)
+	}
 	return &CompositeError{
 		code:    CompositeErrorCode,
 		Errors:  append(make([]error, 0, len(errors)), errors...),
@@ -142,118 +478,265 @@ func CompositeValidationError(errors ...error) *CompositeError {
 	}
 }
 
+// CompositeValidationErrorWithDetail an error to wrap a bunch of other errors, with a human readable summary
+func CompositeValidationErrorWithDetail(detail string, errors ...error) *CompositeError {
+	c := CompositeValidationError(errors...)
+	c.Detail = detail
+	return c
+}
+
+// AutoFlattenComposite makes CompositeValidationError inline the errors of nested composites,
+// so that composites always have a single level.
+var AutoFlattenComposite bool
+
+func inlineComposites(errs []error) []error {
+	res := make([]error, 0, len(errs))
+	for _, err := range errs {
+		ce, ok := err.(*CompositeError)
+		if !ok {
+			res = append(res, err)
+			continue
+		}
+		if ce != nil {
+			res = append(res, flattenComposite(ce).Errors...)
+		}
+	}
+	return res
+}
+
+// ValidationError wraps a single error in a composite validation error, or returns nil when err is nil
+func ValidationError(err error) *CompositeError {
+	if err == nil {
+		return nil
+	}
+	return CompositeValidationError(err)
+}
+
+// MergeComposite concatenates the errors of several composites into a single composite validation error.
+//
+// Nil and empty composites are skipped.
+func MergeComposite(errs ...*CompositeError) *CompositeError {
+	var res []error
+	for _, c := range errs {
+		if c == nil || len(c.Errors) == 0 {
+			continue
+		}
+		res = append(res, c.Errors...)
+	}
+	return CompositeValidationError(res...)
+}
+
+// SortedEqual tells if two composites hold the same errors regardless of their order,
+// comparing the messages of their children as multisets. Nested composites are flattened.
+func SortedEqual(expected, actual *CompositeError) bool {
+	if expected == nil || actual == nil {
+		return expected == actual
+	}
+	exp, act := flattenComposite(expected).Errors, flattenComposite(actual).Errors
+	if len(exp) != len(act) {
+		return false
+	}
+	counts := make(map[string]int, len(exp))
+	for _, e := range exp {
+		counts[e.Error()]++
+	}
+	for _, e := range act {
+		msg := e.Error()
+		if counts[msg] == 0 {
+			return false
+		}
+		counts[msg]--
+	}
+	return true
+}
+
+// UnprocessableEntity is a composite validation error which is always served as a 422
+// with the full list of errors, regardless of the code of its children
+func UnprocessableEntity(errs ...error) Error {
+	c := CompositeValidationError(errs...)
+	c.code = http.StatusUnprocessableEntity
+	c.keepCode = true
+	return c
+}
+
 // ValidateName recursively sets the name for all validations or updates them for nested properties
-func (c *CompositeError) ValidateName(name string) *CompositeError {
+func (c *CompositeError) ValidateName(segments ...string) *CompositeError {
 	for i, e := range c.Errors {
 		if ve, ok := e.(*Validation); ok {
-			c.Errors[i] = ve.ValidateName(name)
+			c.Errors[i] = ve.ValidateName(segments...)
 		} else if ce, ok := e.(*CompositeError); ok {
-			c.Errors[i] = ce.ValidateName(name)
+			c.Errors[i] = ce.ValidateName(segments...)
 		}
 	}
 
 	return c
 }
 
+// PrefixNames re-roots the names of all validations in this composite under prefix, e.g. "address" or "address."
+//
+// Errors other than validations, as well as validations without a name, e.g. MutuallyExclusive, are left untouched.
+func (c *CompositeError) PrefixNames(prefix string) *CompositeError {
+	if c == nil {
+		return nil
+	}
+	prefix = strings.TrimSuffix(prefix, ".")
+	for i, e := range c.Errors {
+		switch ee := e.(type) {
+		case *Validation:
+			if ee != nil && ee.Name != "" {
+				c.Errors[i] = ee.ValidateName(prefix)
+			}
+		case *CompositeError:
+			c.Errors[i] = ee.PrefixNames(prefix)
+		}
+	}
+	return c
+}
+
+// ValidationOption customizes a Validation built by a constructor
+type ValidationOption func(*Validation)
+
+// WithCode overrides the default code of a validation error.
+//
+// The JSON schema keyword of the validation is retained.
+func WithCode(code int32) ValidationOption {
+	return func(v *Validation) {
+		v.keyword = v.Keyword()
+		v.code = code
+	}
+}
+
+func withOptions(v *Validation, opts []ValidationOption) *Validation {
+	for _, apply := range opts {
+		apply(v)
+	}
+	return v
+}
+
+// IndexedName builds the name of an array element, e.g. "tags[2]"
+func IndexedName(base string, index int) string {
+	return fmt.Sprintf("%s[%d]", base, index)
+}
+
 // FailedAllPatternProperties an error for when the property doesn't match a pattern
-func FailedAllPatternProperties(name, in, key string) *Validation {
+func FailedAllPatternProperties(name, in, key string, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(failedAllPatternProps, name, key, in)
 	if in == "" {
 		msg = fmt.Sprintf(failedAllPatternPropsNoIn, name, key)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    FailedAllPatternPropsCode,
 		Name:    name,
 		In:      in,
 		Value:   key,
 		message: msg,
-	}
+	}, opts)
 }
 
 // PropertyNotAllowed an error for when the property doesn't match a pattern
-func PropertyNotAllowed(name, in, key string) *Validation {
+func PropertyNotAllowed(name, in, key string, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(unallowedProperty, name, key, in)
 	if in == "" {
 		msg = fmt.Sprintf(unallowedPropertyNoIn, name, key)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    UnallowedPropertyCode,
 		Name:    name,
 		In:      in,
 		Value:   key,
 		message: msg,
-	}
+	}, opts)
 }
 
 // TooFewProperties an error for an object with too few properties
-func TooFewProperties(name, in string, n int64) *Validation {
+func TooFewProperties(name, in string, n int64, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(tooFewProperties, name, in, n)
 	if in == "" {
 		msg = fmt.Sprintf(tooFewPropertiesNoIn, name, n)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    TooFewPropertiesCode,
 		Name:    name,
 		In:      in,
 		Value:   n,
 		message: msg,
-	}
+	}, opts)
 }
 
 // TooManyProperties an error for an object with too many properties
-func TooManyProperties(name, in string, n int64) *Validation {
+func TooManyProperties(name, in string, n int64, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(tooManyProperties, name, in, n)
 	if in == "" {
 		msg = fmt.Sprintf(tooManyPropertiesNoIn, name, n)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    TooManyPropertiesCode,
 		Name:    name,
 		In:      in,
 		Value:   n,
 		message: msg,
-	}
+	}, opts)
 }
 
 // AdditionalItemsNotAllowed an error for invalid additional items
-func AdditionalItemsNotAllowed(name, in string) *Validation {
+func AdditionalItemsNotAllowed(name, in string, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(noAdditionalItems, name, in)
 	if in == "" {
 		msg = fmt.Sprintf(noAdditionalItemsNoIn, name)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    NoAdditionalItemsCode,
 		Name:    name,
 		In:      in,
 		message: msg,
-	}
+	}, opts)
 }
 
 // InvalidCollectionFormat another flavor of invalid type error
-func InvalidCollectionFormat(name, in, format string) *Validation {
-	return &Validation{
+func InvalidCollectionFormat(name, in, format string, opts ...ValidationOption) *Validation {
+	values := make([]interface{}, 0, len(collectionFormats))
+	for _, v := range collectionFormats {
+		values = append(values, v)
+	}
+	return withOptions(&Validation{
 		code:    InvalidTypeCode,
 		Name:    name,
 		In:      in,
 		Value:   format,
-		message: fmt.Sprintf("the collection format %q is not supported for the %s param %q", format, in, name),
-	}
+		Values:  values,
+		keyword: "collectionFormat",
+		message: fmt.Sprintf("the collection format %q is not supported for the %s param %q; supported formats are %v", format, in, name, collectionFormats),
+	}, opts)
 }
 
+// collectionFormats are the collection formats supported by OpenAPI 2 parameters
+var collectionFormats = []string{"csv", "ssv", "tsv", "pipes", "multi"}
+
 // InvalidTypeName an error for when the type is invalid
-func InvalidTypeName(typeName string) *Validation {
-	return &Validation{
+func InvalidTypeName(typeName string, opts ...ValidationOption) *Validation {
+	return withOptions(&Validation{
 		code:    InvalidTypeCode,
 		Value:   typeName,
 		message: fmt.Sprintf(invalidType, typeName),
-	}
+	}, opts)
 }
 
 // InvalidType creates an error for when the type is invalid
-func InvalidType(name, in, typeName string, value interface{}) *Validation {
+func InvalidType(name, in, typeName string, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 
-	if in != "" {
+	if ValueFormatter != nil && value != nil {
+		tmpl, tmplNoIn := typeFailWithFormatted, typeFailWithFormattedNoIn
+		if _, isError := value.(error); isError {
+			tmpl, tmplNoIn = typeFailWithError, typeFailWithErrorNoIn
+		}
+		formatted := ValueFormatter(value)
+		if in != "" {
+			message = fmt.Sprintf(tmpl, name, in, typeName, formatted)
+		} else {
+			message = fmt.Sprintf(tmplNoIn, name, typeName, formatted)
+		}
+	} else if in != "" {
 		switch value.(type) {
 		case string:
 			message = fmt.Sprintf(typeFailWithData, name, in, typeName, value)
@@ -273,63 +756,174 @@ func InvalidType(name, in, typeName string, value interface{}) *Validation {
 		}
 	}
 
-	return &Validation{
+	return withOptions(&Validation{
 		code:    InvalidTypeCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: message,
+		Hint:    FormatHints[typeName],
+	}, opts)
+}
+
+// InvalidTypeActual an error for when the type is invalid, reporting the type which was actually received
+func InvalidTypeActual(name, in, expected, actual string, value interface{}, opts ...ValidationOption) *Validation {
+	var message string
+	if in == "" {
+		message = fmt.Sprintf(typeFailActualNoIn, name, expected, actual)
+	} else {
+		message = fmt.Sprintf(typeFailActual, name, in, expected, actual)
 	}
+	return withOptions(&Validation{
+		code:         InvalidTypeCode,
+		Name:         name,
+		In:           in,
+		Value:        value,
+		message:      message,
+		Hint:         FormatHints[expected],
+		ExpectedType: expected,
+		ActualType:   actual,
+	}, opts)
+}
 
+// FormatFail an error for when a value does not conform to its string format, e.g. email.
+//
+// The message is specific to the format when registered with RegisterFormatMessage.
+func FormatFail(name, in, format string, value interface{}, opts ...ValidationOption) *Validation {
+	subject := name
+	if in != "" {
+		subject = name + " in " + in
+	}
+	var message string
+	if tmpl, ok := formatMessages[format]; ok {
+		message = fmt.Sprintf(tmpl, subject)
+	} else {
+		message = fmt.Sprintf(formatFail, subject, format)
+	}
+	return withOptions(&Validation{
+		code:    FormatFailCode,
+		Name:    name,
+		In:      in,
+		Value:   value,
+		message: message,
+		Hint:    FormatHints[format],
+	}, opts)
 }
 
 // DuplicateItems error for when an array contains duplicates
-func DuplicateItems(name, in string) *Validation {
+func DuplicateItems(name, in string, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(uniqueFail, name, in)
 	if in == "" {
 		msg = fmt.Sprintf(uniqueFailNoIn, name)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    UniqueFailCode,
 		Name:    name,
 		In:      in,
 		message: msg,
+	}, opts)
+}
+
+// DuplicateItemsAt error for when an array contains duplicates, reporting their positions
+func DuplicateItemsAt(name, in string, indices []int, opts ...ValidationOption) *Validation {
+	msg := fmt.Sprintf(uniqueFailAt, name, in, indices)
+	if in == "" {
+		msg = fmt.Sprintf(uniqueFailAtNoIn, name, indices)
 	}
+	return withOptions(&Validation{
+		code:    UniqueFailCode,
+		Name:    name,
+		In:      in,
+		message: msg,
+		Indices: indices,
+	}, opts)
 }
 
 // TooManyItems error for when an array contains too many items
-func TooManyItems(name, in string, max int64, value interface{}) *Validation {
+func TooManyItems(name, in string, max int64, value interface{}, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(maxItemsFail, name, in, max)
 	if in == "" {
 		msg = fmt.Sprintf(maxItemsFailNoIn, name, max)
 	}
 
-	return &Validation{
+	return withOptions(&Validation{
 		code:    MaxItemsFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
-	}
+	}, opts)
 }
 
 // TooFewItems error for when an array contains too few items
-func TooFewItems(name, in string, min int64, value interface{}) *Validation {
+func TooFewItems(name, in string, min int64, value interface{}, opts ...ValidationOption) *Validation {
 	msg := fmt.Sprintf(minItemsFail, name, in, min)
 	if in == "" {
 		msg = fmt.Sprintf(minItemsFailNoIn, name, min)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    MinItemsFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
+	}, opts)
+}
+
+func maxComparator(exclusive bool) string {
+	if exclusive {
+		return "<"
 	}
+	return "<="
+}
+
+func minComparator(exclusive bool) string {
+	if exclusive {
+		return ">"
+	}
+	return ">="
+}
+
+// DurationTooLong error for when a duration is longer than the maximum, e.g. with format: duration
+func DurationTooLong(name, in string, max, value time.Duration, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(durationTooLongNoIn, name, max)
+	} else {
+		msg = fmt.Sprintf(durationTooLong, name, in, max)
+	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    msg,
+		Comparator: maxComparator(false),
+		Limit:      max.String(),
+	}, opts)
+}
+
+// DurationTooShort error for when a duration is shorter than the minimum, e.g. with format: duration
+func DurationTooShort(name, in string, min, value time.Duration, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(durationTooShortNoIn, name, min)
+	} else {
+		msg = fmt.Sprintf(durationTooShort, name, in, min)
+	}
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    msg,
+		Comparator: minComparator(false),
+		Limit:      min.String(),
+	}, opts)
 }
 
 // ExceedsMaximumInt error for when maximum validation fails
-func ExceedsMaximumInt(name, in string, max int64, exclusive bool, value interface{}) *Validation {
+func ExceedsMaximumInt(name, in string, max int64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := maxIncFailNoIn
@@ -344,17 +938,19 @@ func ExceedsMaximumInt(name, in string, max int64, exclusive bool, value interfa
 		}
 		message = fmt.Sprintf(m, name, in, max)
 	}
-	return &Validation{
-		code:    MaxFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
-	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: maxComparator(exclusive),
+		Limit:      max,
+	}, opts)
 }
 
 // ExceedsMaximumUint error for when maximum validation fails
-func ExceedsMaximumUint(name, in string, max uint64, exclusive bool, value interface{}) *Validation {
+func ExceedsMaximumUint(name, in string, max uint64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := maxIncFailNoIn
@@ -369,17 +965,19 @@ func ExceedsMaximumUint(name, in string, max uint64, exclusive bool, value inter
 		}
 		message = fmt.Sprintf(m, name, in, max)
 	}
-	return &Validation{
-		code:    MaxFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
-	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: maxComparator(exclusive),
+		Limit:      max,
+	}, opts)
 }
 
 // ExceedsMaximum error for when maximum validation fails
-func ExceedsMaximum(name, in string, max float64, exclusive bool, value interface{}) *Validation {
+func ExceedsMaximum(name, in string, max float64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := maxIncFailNoIn
@@ -394,17 +992,19 @@ func ExceedsMaximum(name, in string, max float64, exclusive bool, value interfac
 		}
 		message = fmt.Sprintf(m, name, in, max)
 	}
-	return &Validation{
-		code:    MaxFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
-	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: maxComparator(exclusive),
+		Limit:      max,
+	}, opts)
 }
 
 // ExceedsMinimumInt error for when minimum validation fails
-func ExceedsMinimumInt(name, in string, min int64, exclusive bool, value interface{}) *Validation {
+func ExceedsMinimumInt(name, in string, min int64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := minIncFailNoIn
@@ -419,17 +1019,19 @@ func ExceedsMinimumInt(name, in string, min int64, exclusive bool, value interfa
 		}
 		message = fmt.Sprintf(m, name, in, min)
 	}
-	return &Validation{
-		code:    MinFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
-	}
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: minComparator(exclusive),
+		Limit:      min,
+	}, opts)
 }
 
 // ExceedsMinimumUint error for when minimum validation fails
-func ExceedsMinimumUint(name, in string, min uint64, exclusive bool, value interface{}) *Validation {
+func ExceedsMinimumUint(name, in string, min uint64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := minIncFailNoIn
@@ -444,17 +1046,19 @@ func ExceedsMinimumUint(name, in string, min uint64, exclusive bool, value inter
 		}
 		message = fmt.Sprintf(m, name, in, min)
 	}
-	return &Validation{
-		code:    MinFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
-	}
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: minComparator(exclusive),
+		Limit:      min,
+	}, opts)
 }
 
 // ExceedsMinimum error for when minimum validation fails
-func ExceedsMinimum(name, in string, min float64, exclusive bool, value interface{}) *Validation {
+func ExceedsMinimum(name, in string, min float64, exclusive bool, value interface{}, opts ...ValidationOption) *Validation {
 	var message string
 	if in == "" {
 		m := minIncFailNoIn
@@ -469,34 +1073,90 @@ func ExceedsMinimum(name, in string, min float64, exclusive bool, value interfac
 		}
 		message = fmt.Sprintf(m, name, in, min)
 	}
-	return &Validation{
-		code:    MinFailCode,
-		Name:    name,
-		In:      in,
-		Value:   value,
-		message: message,
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: minComparator(exclusive),
+		Limit:      min,
+	}, opts)
+}
+
+// LexicallyAboveMaximum error for when a lexical maximum validation fails on a string
+func LexicallyAboveMaximum(name, in, max, value string, exclusive bool, opts ...ValidationOption) *Validation {
+	var message string
+	if in == "" {
+		m := maxIncFailLexicalNoIn
+		if exclusive {
+			m = maxExcFailLexicalNoIn
+		}
+		message = fmt.Sprintf(m, name, max)
+	} else {
+		m := maxIncFailLexical
+		if exclusive {
+			m = maxExcFailLexical
+		}
+		message = fmt.Sprintf(m, name, in, max)
 	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: maxComparator(exclusive),
+		Limit:      max,
+	}, opts)
+}
+
+// LexicallyBelowMinimum error for when a lexical minimum validation fails on a string
+func LexicallyBelowMinimum(name, in, min, value string, exclusive bool, opts ...ValidationOption) *Validation {
+	var message string
+	if in == "" {
+		m := minIncFailLexicalNoIn
+		if exclusive {
+			m = minExcFailLexicalNoIn
+		}
+		message = fmt.Sprintf(m, name, min)
+	} else {
+		m := minIncFailLexical
+		if exclusive {
+			m = minExcFailLexical
+		}
+		message = fmt.Sprintf(m, name, in, min)
+	}
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      value,
+		message:    message,
+		Comparator: minComparator(exclusive),
+		Limit:      min,
+	}, opts)
 }
 
 // NotMultipleOf error for when multiple of validation fails
-func NotMultipleOf(name, in string, multiple, value interface{}) *Validation {
+func NotMultipleOf(name, in string, multiple, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(multipleOfFailNoIn, name, multiple)
 	} else {
 		msg = fmt.Sprintf(multipleOfFail, name, in, multiple)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    MultipleOfFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
-	}
+	}, opts)
 }
 
 // EnumFail error for when an enum validation fails
-func EnumFail(name, in string, value interface{}, values []interface{}) *Validation {
+func EnumFail(name, in string, value interface{}, values []interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(enumFailNoIn, name, values)
@@ -504,69 +1164,234 @@ func EnumFail(name, in string, value interface{}, values []interface{}) *Validat
 		msg = fmt.Sprintf(enumFail, name, in, values)
 	}
 
-	return &Validation{
+	return withOptions(&Validation{
 		code:    EnumFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		Values:  values,
 		message: msg,
+	}, opts)
+}
+
+// ForbiddenValue error for when a value is one of the forbidden values, the inverse of EnumFail
+func ForbiddenValue(name, in string, value interface{}, forbidden []interface{}, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(forbiddenFailNoIn, name, forbidden)
+	} else {
+		msg = fmt.Sprintf(forbiddenFail, name, in, forbidden)
 	}
+
+	return withOptions(&Validation{
+		code:    ForbiddenValueCode,
+		Name:    name,
+		In:      in,
+		Value:   value,
+		Values:  forbidden,
+		message: msg,
+	}, opts)
 }
 
 // Required error for when a value is missing
-func Required(name, in string, value interface{}) *Validation {
+func Required(name, in string, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(requiredFailNoIn, name)
 	} else {
 		msg = fmt.Sprintf(requiredFail, name, in)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    RequiredFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
+	}, opts)
+}
+
+// EmptyNotAllowed error for when a value is present but empty, and empty values are not allowed
+func EmptyNotAllowed(name, in string, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(emptyNotAllowedNoIn, name)
+	} else {
+		msg = fmt.Sprintf(emptyNotAllowed, name, in)
+	}
+	return withOptions(&Validation{
+		code:    EmptyNotAllowedCode,
+		Name:    name,
+		In:      in,
+		message: msg,
+	}, opts)
+}
+
+// MustBeNull error for when a value is not null, while its schema only admits null, e.g. type: "null"
+func MustBeNull(name, in string, value interface{}, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(mustBeNullNoIn, name)
+	} else {
+		msg = fmt.Sprintf(mustBeNull, name, in)
+	}
+	return withOptions(&Validation{
+		code:    MustBeNullCode,
+		Name:    name,
+		In:      in,
+		Value:   value,
+		message: msg,
+	}, opts)
+}
+
+// PageSizeExceeded error for when a list request asks for a page larger than the maximum page size
+func PageSizeExceeded(name, in string, max, requested int64, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(pageSizeExceededNoIn, name, requested, max)
+	} else {
+		msg = fmt.Sprintf(pageSizeExceeded, name, in, requested, max)
 	}
+	return withOptions(&Validation{
+		code:       PageSizeExceededCode,
+		Name:       name,
+		In:         in,
+		Value:      requested,
+		message:    msg,
+		Comparator: maxComparator(false),
+		Limit:      max,
+	}, opts)
+}
+
+// UnknownParam error for when a request carries an undeclared query or header parameter
+func UnknownParam(name, in string, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(unknownParamNoIn, name)
+	} else {
+		msg = fmt.Sprintf(unknownParam, name, in)
+	}
+	return withOptions(&Validation{
+		code:    UnknownParamCode,
+		Name:    name,
+		In:      in,
+		message: msg,
+	}, opts)
+}
+
+// MutuallyExclusive error for when several parameters are provided, while only one of them may be.
+//
+// The conflicting names are reported as the values of this validation.
+func MutuallyExclusive(in string, names []string, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(mutuallyExclusiveNoIn, names)
+	} else {
+		msg = fmt.Sprintf(mutuallyExclusive, names, in)
+	}
+	return withOptions(&Validation{
+		code:    MutuallyExclusiveCode,
+		In:      in,
+		Values:  namesAsValues(names),
+		message: msg,
+	}, opts)
+}
+
+// AtLeastOneRequired error for when none of a group of parameters is provided, while at least one of them must be.
+//
+// The names of the group are reported as the values of this validation.
+func AtLeastOneRequired(in string, names []string, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(atLeastOneRequiredNoIn, names)
+	} else {
+		msg = fmt.Sprintf(atLeastOneRequired, names, in)
+	}
+	return withOptions(&Validation{
+		code:    AtLeastOneRequiredCode,
+		In:      in,
+		Values:  namesAsValues(names),
+		message: msg,
+	}, opts)
+}
+
+func namesAsValues(names []string) []interface{} {
+	values := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		values = append(values, name)
+	}
+	return values
 }
 
 // ReadOnly error for when a value is present in request
-func ReadOnly(name, in string, value interface{}) *Validation {
+func ReadOnly(name, in string, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(readOnlyFailNoIn, name)
 	} else {
 		msg = fmt.Sprintf(readOnlyFail, name, in)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    ReadOnlyFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
+	}, opts)
+}
+
+// ReadOnlyModified error for when a readOnly value is modified in request, reporting the attempted value
+func ReadOnlyModified(name, in string, attempted interface{}, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(readOnlyModifiedNoIn, name, attempted)
+	} else {
+		msg = fmt.Sprintf(readOnlyModified, name, in, attempted)
+	}
+	return withOptions(&Validation{
+		code:    ReadOnlyFailCode,
+		Name:    name,
+		In:      in,
+		Value:   attempted,
+		message: msg,
+	}, opts)
+}
+
+// WriteOnly error for when a value is present in response
+func WriteOnly(name, in string, value interface{}, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(writeOnlyFailNoIn, name)
+	} else {
+		msg = fmt.Sprintf(writeOnlyFail, name, in)
 	}
+	return withOptions(&Validation{
+		code:    WriteOnlyFailCode,
+		Name:    name,
+		In:      in,
+		Value:   value,
+		message: msg,
+	}, opts)
 }
 
 // TooLong error for when a string is too long
-func TooLong(name, in string, max int64, value interface{}) *Validation {
+func TooLong(name, in string, max int64, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(tooLongMessageNoIn, name, max)
 	} else {
 		msg = fmt.Sprintf(tooLongMessage, name, in, max)
 	}
-	return &Validation{
+	return withOptions(&Validation{
 		code:    TooLongFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
-	}
+	}, opts)
 }
 
 // TooShort error for when a string is too short
-func TooShort(name, in string, min int64, value interface{}) *Validation {
+func TooShort(name, in string, min int64, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(tooShortMessageNoIn, name, min)
@@ -574,18 +1399,109 @@ func TooShort(name, in string, min int64, value interface{}) *Validation {
 		msg = fmt.Sprintf(tooShortMessage, name, in, min)
 	}
 
-	return &Validation{
+	return withOptions(&Validation{
 		code:    TooShortFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
+	}, opts)
+}
+
+// DecodedTooLong error for when a base64 encoded string is too long once decoded, e.g. with format: byte
+func DecodedTooLong(name, in string, max, decodedLen int64, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(decodedTooLongNoIn, name, max)
+	} else {
+		msg = fmt.Sprintf(decodedTooLong, name, in, max)
 	}
+	return withOptions(&Validation{
+		code:    TooLongFailCode,
+		Name:    name,
+		In:      in,
+		Value:   decodedLen,
+		message: msg,
+	}, opts)
+}
+
+// DecodedTooShort error for when a base64 encoded string is too short once decoded, e.g. with format: byte
+func DecodedTooShort(name, in string, min, decodedLen int64, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(decodedTooShortNoIn, name, min)
+	} else {
+		msg = fmt.Sprintf(decodedTooShort, name, in, min)
+	}
+	return withOptions(&Validation{
+		code:    TooShortFailCode,
+		Name:    name,
+		In:      in,
+		Value:   decodedLen,
+		message: msg,
+	}, opts)
+}
+
+// FileTooLarge error for when a file or a multipart upload is larger than allowed
+func FileTooLarge(name, in string, maxBytes, actualBytes int64, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(fileTooLargeNoIn, name, humanSize(maxBytes))
+	} else {
+		msg = fmt.Sprintf(fileTooLarge, name, in, humanSize(maxBytes))
+	}
+	return withOptions(&Validation{
+		code:       MaxFailCode,
+		Name:       name,
+		In:         in,
+		Value:      actualBytes,
+		message:    msg,
+		Comparator: maxComparator(false),
+		Limit:      maxBytes,
+	}, opts)
+}
+
+// FileTooSmall error for when a file or a multipart upload is smaller than allowed
+func FileTooSmall(name, in string, minBytes, actualBytes int64, opts ...ValidationOption) *Validation {
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(fileTooSmallNoIn, name, humanSize(minBytes))
+	} else {
+		msg = fmt.Sprintf(fileTooSmall, name, in, humanSize(minBytes))
+	}
+	return withOptions(&Validation{
+		code:       MinFailCode,
+		Name:       name,
+		In:         in,
+		Value:      actualBytes,
+		message:    msg,
+		Comparator: minComparator(false),
+		Limit:      minBytes,
+	}, opts)
+}
+
+// humanSize renders a size in bytes with binary units, e.g. "5 MB" for 5*1024*1024 bytes
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit && size > -unit {
+		return fmt.Sprintf("%d bytes", size)
+	}
+	units := []string{"KB", "MB", "GB", "TB", "PB", "EB"}
+	value := float64(size) / unit
+	i := 0
+	for (value >= unit || value <= -unit) && i < len(units)-1 {
+		value /= unit
+		i++
+	}
+	if value == float64(int64(value)) {
+		return fmt.Sprintf("%d %s", int64(value), units[i])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[i])
 }
 
 // FailedPattern error for when a string fails a regex pattern match
 // the pattern that is returned is the ECMA syntax version of the pattern not the golang version.
-func FailedPattern(name, in, pattern string, value interface{}) *Validation {
+func FailedPattern(name, in, pattern string, value interface{}, opts ...ValidationOption) *Validation {
 	var msg string
 	if in == "" {
 		msg = fmt.Sprintf(patternFailNoIn, name, pattern)
@@ -593,23 +1509,54 @@ func FailedPattern(name, in, pattern string, value interface{}) *Validation {
 		msg = fmt.Sprintf(patternFail, name, in, pattern)
 	}
 
-	return &Validation{
+	return withOptions(&Validation{
 		code:    PatternFailCode,
 		Name:    name,
 		In:      in,
 		Value:   value,
 		message: msg,
+	}, opts)
+}
+
+// FailedAllPatterns error for when a string fails to match any of several regex patterns
+func FailedAllPatterns(name, in string, patterns []string, value interface{}, opts ...ValidationOption) *Validation {
+	quoted := make([]string, 0, len(patterns))
+	values := make([]interface{}, 0, len(patterns))
+	for _, p := range patterns {
+		quoted = append(quoted, "'"+p+"'")
+		values = append(values, p)
+	}
+
+	var msg string
+	if in == "" {
+		msg = fmt.Sprintf(patternsFailNoIn, name, strings.Join(quoted, " "))
+	} else {
+		msg = fmt.Sprintf(patternsFail, name, in, strings.Join(quoted, " "))
 	}
+
+	return withOptions(&Validation{
+		code:    PatternFailCode,
+		Name:    name,
+		In:      in,
+		Value:   value,
+		Values:  values,
+		message: msg,
+	}, opts)
 }
 
 // MultipleOfMustBePositive error for when a
 // multipleOf factor is negative
-func MultipleOfMustBePositive(name, in string, factor interface{}) *Validation {
-	return &Validation{
+func MultipleOfMustBePositive(name, in string, factor interface{}, opts ...ValidationOption) *Validation {
+	return withOptions(&Validation{
 		code:    MultipleOfMustBePositiveCode,
 		Name:    name,
 		In:      in,
 		Value:   factor,
 		message: fmt.Sprintf(multipleOfMustBePositive, name, factor),
-	}
+	}, opts)
+}
+
+// RecursionLimitExceeded error for when validation recurses beyond some depth, e.g. with a self-referential schema
+func RecursionLimitExceeded(name string, depth int) Error {
+	return NewWithStatus(RecursionLimitExceededCode, http.StatusBadRequest, recursionLimitExceeded, name, depth)
 }
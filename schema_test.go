@@ -16,7 +16,11 @@ package errors
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -56,6 +60,80 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "confirmed must be of type boolean, because: hello", err.Error())
 	})
 
+	t.Run("with InvalidTypeActual", func(t *testing.T) {
+		err := InvalidTypeActual("age", "query", "integer", "string", "ten")
+		require.Error(t, err)
+		assert.EqualValues(t, InvalidTypeCode, err.Code())
+		assert.Equal(t, "age in query must be of type integer, got string", err.Error())
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"expectedType":"integer"`)
+		assert.Contains(t, string(jazon), `"actualType":"string"`)
+
+		err = InvalidTypeActual("age", "", "integer", "string", "ten")
+		require.Error(t, err)
+		assert.EqualValues(t, InvalidTypeCode, err.Code())
+		assert.Equal(t, "age must be of type integer, got string", err.Error())
+	})
+
+	t.Run("with FormatFail", func(t *testing.T) {
+		err := FormatFail("contact", "body", "email", "x@")
+		require.Error(t, err)
+		assert.EqualValues(t, FormatFailCode, err.Code())
+		assert.Equal(t, "contact in body must be a valid email address", err.Error())
+		assert.Equal(t, "format", err.Keyword())
+
+		err = FormatFail("contact", "", "email", "x@")
+		assert.Equal(t, "contact must be a valid email address", err.Error())
+
+		err = FormatFail("color", "query", "hexcolor", "red")
+		assert.Equal(t, "color in query must conform to format 'hexcolor'", err.Error())
+
+		defer delete(formatMessages, "hexcolor")
+		RegisterFormatMessage("hexcolor", "%s must be a color such as #ff0000")
+		err = FormatFail("color", "query", "hexcolor", "red")
+		assert.Equal(t, "color in query must be a color such as #ff0000", err.Error())
+	})
+
+	t.Run("with InvalidType and a ValueFormatter", func(t *testing.T) {
+		oldValueFormatter := ValueFormatter
+		defer func() { ValueFormatter = oldValueFormatter }()
+		ValueFormatter = func(v interface{}) string {
+			return fmt.Sprintf("<%v>", v)
+		}
+
+		err := InvalidType("confirmed", "query", "boolean", "hello")
+		require.Error(t, err)
+		assert.Equal(t, "confirmed in query must be of type boolean: <hello>", err.Error())
+		assert.Equal(t, "hello", err.Value)
+
+		err = InvalidType("confirmed", "", "boolean", 12)
+		require.Error(t, err)
+		assert.Equal(t, "confirmed must be of type boolean: <12>", err.Error())
+
+		err = InvalidType("confirmed", "query", "boolean", errors.New("hello"))
+		require.Error(t, err)
+		assert.Equal(t, "confirmed in query must be of type boolean, because: <hello>", err.Error())
+
+		err = InvalidType("confirmed", "query", "boolean", nil)
+		require.Error(t, err)
+		assert.Equal(t, "confirmed in query must be of type boolean", err.Error())
+	})
+
+	t.Run("with IndexedName", func(t *testing.T) {
+		name := IndexedName("tags", 2)
+		assert.Equal(t, "tags[2]", name)
+
+		err := InvalidType(name, "body", "string", nil)
+		require.Error(t, err)
+		assert.Equal(t, "tags[2] in body must be of type string", err.Error())
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"name":"tags[2]"`)
+	})
+
 	t.Run("with DuplicateItems", func(t *testing.T) {
 		err := DuplicateItems("uniques", "query")
 		require.Error(t, err)
@@ -68,6 +146,22 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "uniques shouldn't contain duplicates", err.Error())
 	})
 
+	t.Run("with DuplicateItemsAt", func(t *testing.T) {
+		err := DuplicateItemsAt("uniques", "query", []int{2, 5})
+		require.Error(t, err)
+		assert.EqualValues(t, UniqueFailCode, err.Code())
+		assert.Equal(t, "uniques in query contains duplicates at positions [2 5]", err.Error())
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"indices":[2,5]`)
+
+		err = DuplicateItemsAt("uniques", "", []int{2, 5})
+		require.Error(t, err)
+		assert.EqualValues(t, UniqueFailCode, err.Code())
+		assert.Equal(t, "uniques contains duplicates at positions [2 5]", err.Error())
+	})
+
 	t.Run("with TooMany/TooFew Items", func(t *testing.T) {
 		err := TooManyItems("something", "query", 5, 6)
 		require.Error(t, err)
@@ -88,6 +182,51 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, 4, err.Value)
 	})
 
+	t.Run("with comparator and limit in JSON", func(t *testing.T) {
+		err := ExceedsMaximumInt("something", "query", 5, false, 6)
+		assert.Equal(t, "<=", err.Comparator)
+		assert.EqualValues(t, 5, err.Limit)
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"comparator":"\u003c="`)
+		assert.Contains(t, string(jazon), `"limit":5`)
+
+		err = ExceedsMinimum("something", "query", 1.5, true, 1)
+		assert.Equal(t, ">", err.Comparator)
+		assert.EqualValues(t, 1.5, err.Limit)
+
+		err = LexicallyBelowMinimum("something", "query", "b", "a", false)
+		assert.Equal(t, ">=", err.Comparator)
+		assert.Equal(t, "b", err.Limit)
+
+		jazon, jerr = Required("something", "query", nil).MarshalJSON()
+		require.NoError(t, jerr)
+		assert.NotContains(t, string(jazon), `"comparator"`)
+		assert.NotContains(t, string(jazon), `"limit"`)
+	})
+
+	t.Run("with DurationTooLong/DurationTooShort", func(t *testing.T) {
+		err := DurationTooLong("timeout", "query", 90*time.Second, 2*time.Hour)
+		require.Error(t, err)
+		assert.EqualValues(t, MaxFailCode, err.Code())
+		assert.Equal(t, "timeout in query should be a duration of at most 1m30s", err.Error())
+		assert.Equal(t, 2*time.Hour, err.Value)
+
+		err = DurationTooLong("timeout", "", 90*time.Second, 2*time.Hour)
+		require.Error(t, err)
+		assert.Equal(t, "timeout should be a duration of at most 1m30s", err.Error())
+
+		err = DurationTooShort("timeout", "query", time.Second, time.Millisecond)
+		require.Error(t, err)
+		assert.EqualValues(t, MinFailCode, err.Code())
+		assert.Equal(t, "timeout in query should be a duration of at least 1s", err.Error())
+		assert.Equal(t, time.Millisecond, err.Value)
+
+		err = DurationTooShort("timeout", "", time.Second, time.Millisecond)
+		require.Error(t, err)
+		assert.Equal(t, "timeout should be a duration of at least 1s", err.Error())
+	})
+
 	t.Run("with ExceedsMaximum", func(t *testing.T) {
 		err := ExceedsMaximumInt("something", "query", 5, false, 6)
 		require.Error(t, err)
@@ -242,6 +381,30 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, 1, err.Value)
 	})
 
+	t.Run("with lexical bounds", func(t *testing.T) {
+		err := LexicallyAboveMaximum("version", "query", "v2", "v3", false)
+		require.Error(t, err)
+		assert.EqualValues(t, MaxFailCode, err.Code())
+		assert.Equal(t, "version in query should be lexically less than or equal to 'v2'", err.Error())
+		assert.Equal(t, "v3", err.Value)
+
+		err = LexicallyAboveMaximum("version", "", "v2", "v2", true)
+		require.Error(t, err)
+		assert.EqualValues(t, MaxFailCode, err.Code())
+		assert.Equal(t, "version should be lexically less than 'v2'", err.Error())
+
+		err = LexicallyBelowMinimum("version", "query", "v2", "v1", true)
+		require.Error(t, err)
+		assert.EqualValues(t, MinFailCode, err.Code())
+		assert.Equal(t, "version in query should be lexically greater than 'v2'", err.Error())
+		assert.Equal(t, "v1", err.Value)
+
+		err = LexicallyBelowMinimum("version", "", "v2", "v1", false)
+		require.Error(t, err)
+		assert.EqualValues(t, MinFailCode, err.Code())
+		assert.Equal(t, "version should be lexically greater than or equal to 'v2'", err.Error())
+	})
+
 	t.Run("with MultipleOf", func(t *testing.T) {
 		err := NotMultipleOf("something", "query", float64(5), float64(1))
 		require.Error(t, err)
@@ -288,6 +451,31 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "yada", err.Value)
 	})
 
+	t.Run("with ForbiddenValue", func(t *testing.T) {
+		err := ForbiddenValue("something", "query", "admin", []interface{}{"admin", "root"})
+		require.Error(t, err)
+		assert.EqualValues(t, ForbiddenValueCode, err.Code())
+		assert.Equal(t, "something in query must not be one of [admin root]", err.Error())
+		assert.Equal(t, "admin", err.Value)
+		assert.Equal(t, []interface{}{"admin", "root"}, err.Values)
+
+		err = ForbiddenValue("something", "", "admin", []interface{}{"admin", "root"})
+		require.Error(t, err)
+		assert.EqualValues(t, ForbiddenValueCode, err.Code())
+		assert.Equal(t, "something must not be one of [admin root]", err.Error())
+	})
+
+	t.Run("with WithCode option", func(t *testing.T) {
+		err := Required("something", "query", nil, WithCode(700))
+		require.Error(t, err)
+		assert.EqualValues(t, 700, err.Code())
+		assert.Equal(t, "something in query is required", err.Error())
+		assert.Equal(t, "required", err.Keyword())
+
+		err = Required("something", "query", nil)
+		assert.EqualValues(t, RequiredFailCode, err.Code())
+	})
+
 	t.Run("with Required", func(t *testing.T) {
 		err := Required("something", "query", nil)
 		require.Error(t, err)
@@ -302,6 +490,90 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Nil(t, err.Value)
 	})
 
+	t.Run("with EmptyNotAllowed", func(t *testing.T) {
+		err := EmptyNotAllowed("name", "query")
+		require.Error(t, err)
+		assert.EqualValues(t, EmptyNotAllowedCode, err.Code())
+		assert.Equal(t, "name in query may not be empty", err.Error())
+
+		err = EmptyNotAllowed("name", "")
+		require.Error(t, err)
+		assert.EqualValues(t, EmptyNotAllowedCode, err.Code())
+		assert.Equal(t, "name may not be empty", err.Error())
+	})
+
+	t.Run("with MustBeNull", func(t *testing.T) {
+		err := MustBeNull("deletedAt", "body", "2024-01-01")
+		require.Error(t, err)
+		assert.EqualValues(t, MustBeNullCode, err.Code())
+		assert.Equal(t, "deletedAt in body must be null", err.Error())
+		assert.Equal(t, "2024-01-01", err.Value)
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"value":"2024-01-01"`)
+
+		err = MustBeNull("deletedAt", "", 0)
+		require.Error(t, err)
+		assert.EqualValues(t, MustBeNullCode, err.Code())
+		assert.Equal(t, "deletedAt must be null", err.Error())
+	})
+
+	t.Run("with PageSizeExceeded", func(t *testing.T) {
+		err := PageSizeExceeded("limit", "query", 100, 500)
+		require.Error(t, err)
+		assert.EqualValues(t, PageSizeExceededCode, err.Code())
+		assert.Equal(t, "limit in query page size 500 exceeds maximum 100", err.Error())
+		assert.EqualValues(t, 500, err.Value)
+
+		err = PageSizeExceeded("limit", "", 100, 500)
+		require.Error(t, err)
+		assert.EqualValues(t, PageSizeExceededCode, err.Code())
+		assert.Equal(t, "limit page size 500 exceeds maximum 100", err.Error())
+	})
+
+	t.Run("with UnknownParam", func(t *testing.T) {
+		err := UnknownParam("debug", "query")
+		require.Error(t, err)
+		assert.EqualValues(t, UnknownParamCode, err.Code())
+		assert.Equal(t, "unknown parameter 'debug' in query is not allowed", err.Error())
+
+		err = UnknownParam("debug", "")
+		require.Error(t, err)
+		assert.EqualValues(t, UnknownParamCode, err.Code())
+		assert.Equal(t, "unknown parameter 'debug' is not allowed", err.Error())
+	})
+
+	t.Run("with MutuallyExclusive", func(t *testing.T) {
+		err := MutuallyExclusive("query", []string{"a", "b"})
+		require.Error(t, err)
+		assert.EqualValues(t, MutuallyExclusiveCode, err.Code())
+		assert.Equal(t, "only one of [a b] may be provided in query", err.Error())
+		assert.Equal(t, []interface{}{"a", "b"}, err.Values)
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"values":["a","b"]`)
+
+		err = MutuallyExclusive("", []string{"a", "b"})
+		require.Error(t, err)
+		assert.EqualValues(t, MutuallyExclusiveCode, err.Code())
+		assert.Equal(t, "only one of [a b] may be provided", err.Error())
+	})
+
+	t.Run("with AtLeastOneRequired", func(t *testing.T) {
+		err := AtLeastOneRequired("query", []string{"a", "b"})
+		require.Error(t, err)
+		assert.EqualValues(t, AtLeastOneRequiredCode, err.Code())
+		assert.Equal(t, "at least one of [a b] is required in query", err.Error())
+		assert.Equal(t, []interface{}{"a", "b"}, err.Values)
+
+		err = AtLeastOneRequired("", []string{"a", "b"})
+		require.Error(t, err)
+		assert.EqualValues(t, AtLeastOneRequiredCode, err.Code())
+		assert.Equal(t, "at least one of [a b] is required", err.Error())
+	})
+
 	t.Run("with ReadOnly", func(t *testing.T) {
 		err := ReadOnly("something", "query", nil)
 		require.Error(t, err)
@@ -316,6 +588,72 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Nil(t, err.Value)
 	})
 
+	t.Run("with ReadOnlyModified", func(t *testing.T) {
+		err := ReadOnlyModified("id", "body", 42)
+		require.Error(t, err)
+		assert.EqualValues(t, ReadOnlyFailCode, err.Code())
+		assert.Equal(t, "id in body is readOnly and cannot be modified to 42", err.Error())
+		assert.Equal(t, 42, err.Value)
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"value":42`)
+
+		err = ReadOnlyModified("id", "", "abc")
+		require.Error(t, err)
+		assert.Equal(t, "id is readOnly and cannot be modified to abc", err.Error())
+	})
+
+	t.Run("with nil value omitted from JSON", func(t *testing.T) {
+		jazon, err := Required("id", "body", nil).MarshalJSON()
+		require.NoError(t, err)
+		assert.NotContains(t, string(jazon), `"value"`)
+
+		jazon, err = EnumFail("kind", "query", "", []interface{}{"a", "b"}).MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(jazon), `"value":""`)
+
+		jazon, err = ExceedsMaximum("count", "query", -1, false, 0).MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(jazon), `"value":0`)
+	})
+
+	t.Run("with WriteOnly", func(t *testing.T) {
+		err := WriteOnly("something", "body", nil)
+		require.Error(t, err)
+		assert.EqualValues(t, WriteOnlyFailCode, err.Code())
+		assert.Equal(t, "something in body is writeOnly", err.Error())
+		assert.Nil(t, err.Value)
+
+		err = WriteOnly("something", "", nil)
+		require.Error(t, err)
+		assert.EqualValues(t, WriteOnlyFailCode, err.Code())
+		assert.Equal(t, "something is writeOnly", err.Error())
+		assert.Nil(t, err.Value)
+	})
+
+	t.Run("with DecodedTooLong/DecodedTooShort", func(t *testing.T) {
+		err := DecodedTooLong("avatar", "body", 1024, 2048)
+		require.Error(t, err)
+		assert.EqualValues(t, TooLongFailCode, err.Code())
+		assert.Equal(t, "avatar in body should be at most 1024 bytes long once decoded", err.Error())
+		assert.EqualValues(t, 2048, err.Value)
+
+		err = DecodedTooLong("avatar", "", 1024, 2048)
+		require.Error(t, err)
+		assert.Equal(t, "avatar should be at most 1024 bytes long once decoded", err.Error())
+
+		err = DecodedTooShort("avatar", "body", 16, 4)
+		require.Error(t, err)
+		assert.EqualValues(t, TooShortFailCode, err.Code())
+		assert.Equal(t, "avatar in body should be at least 16 bytes long once decoded", err.Error())
+		assert.EqualValues(t, 4, err.Value)
+
+		err = DecodedTooShort("avatar", "", 16, 4)
+		require.Error(t, err)
+		assert.Equal(t, "avatar should be at least 16 bytes long once decoded", err.Error())
+	})
+
 	t.Run("with TooLong/TooShort", func(t *testing.T) {
 		err := TooLong("something", "query", 5, "abcdef")
 		require.Error(t, err)
@@ -342,6 +680,32 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "a", err.Value)
 	})
 
+	t.Run("with FileTooLarge/FileTooSmall", func(t *testing.T) {
+		err := FileTooLarge("upload", "formData", 5<<20, 6<<20)
+		require.Error(t, err)
+		assert.EqualValues(t, MaxFailCode, err.Code())
+		assert.Equal(t, "upload in formData should be at most 5 MB", err.Error())
+		assert.Equal(t, int64(6<<20), err.Value)
+		assert.Equal(t, "<=", err.Comparator)
+		assert.Equal(t, int64(5<<20), err.Limit)
+
+		err = FileTooLarge("upload", "", 1536, 2048)
+		require.Error(t, err)
+		assert.Equal(t, "upload should be at most 1.5 KB", err.Error())
+
+		err = FileTooSmall("upload", "formData", 100, 10)
+		require.Error(t, err)
+		assert.EqualValues(t, MinFailCode, err.Code())
+		assert.Equal(t, "upload in formData should be at least 100 bytes", err.Error())
+		assert.Equal(t, int64(10), err.Value)
+		assert.Equal(t, ">=", err.Comparator)
+		assert.Equal(t, int64(100), err.Limit)
+
+		err = FileTooSmall("upload", "", 1<<30, 10)
+		require.Error(t, err)
+		assert.Equal(t, "upload should be at least 1 GB", err.Error())
+	})
+
 	t.Run("with FailedPattern", func(t *testing.T) {
 		err := FailedPattern("something", "query", "\\d+", "a")
 		require.Error(t, err)
@@ -356,6 +720,20 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "a", err.Value)
 	})
 
+	t.Run("with FailedAllPatterns", func(t *testing.T) {
+		err := FailedAllPatterns("something", "query", []string{"\\d+", "[a-z]+"}, "A")
+		require.Error(t, err)
+		assert.EqualValues(t, PatternFailCode, err.Code())
+		assert.Equal(t, "something in query should match one of ['\\d+' '[a-z]+']", err.Error())
+		assert.Equal(t, "A", err.Value)
+		assert.Equal(t, []interface{}{"\\d+", "[a-z]+"}, err.Values)
+
+		err = FailedAllPatterns("something", "", []string{"\\d+", "[a-z]+"}, "A")
+		require.Error(t, err)
+		assert.EqualValues(t, PatternFailCode, err.Code())
+		assert.Equal(t, "something should match one of ['\\d+' '[a-z]+']", err.Error())
+	})
+
 	t.Run("with InvalidType", func(t *testing.T) {
 		err := InvalidTypeName("something")
 		require.Error(t, err)
@@ -363,6 +741,17 @@ func TestSchemaErrors(t *testing.T) {
 		assert.Equal(t, "something is an invalid type name", err.Error())
 	})
 
+	t.Run("with RecursionLimitExceeded", func(t *testing.T) {
+		err := RecursionLimitExceeded("pet.parent", 64)
+		require.Error(t, err)
+		assert.EqualValues(t, RecursionLimitExceededCode, err.Code())
+		assert.Equal(t, "validation recursion limit exceeded at pet.parent (depth 64)", err.Error())
+
+		recorder := httptest.NewRecorder()
+		ServeError(recorder, nil, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
 	t.Run("with AdditionalItemsNotAllowed", func(t *testing.T) {
 		err := AdditionalItemsNotAllowed("something", "query")
 		require.Error(t, err)
@@ -378,7 +767,9 @@ func TestSchemaErrors(t *testing.T) {
 	err := InvalidCollectionFormat("something", "query", "yada")
 	require.Error(t, err)
 	assert.EqualValues(t, InvalidTypeCode, err.Code())
-	assert.Equal(t, "the collection format \"yada\" is not supported for the query param \"something\"", err.Error())
+	assert.Equal(t, "the collection format \"yada\" is not supported for the query param \"something\"; supported formats are [csv ssv tsv pipes multi]", err.Error())
+	assert.Equal(t, []interface{}{"csv", "ssv", "tsv", "pipes", "multi"}, err.Values)
+	assert.Equal(t, "collectionFormat", err.Keyword())
 
 	t.Run("with CompositeValidationError", func(t *testing.T) {
 		err := CompositeValidationError()
@@ -397,6 +788,269 @@ func TestSchemaErrors(t *testing.T) {
 		require.ErrorIs(t, err, testErr2)
 	})
 
+	t.Run("with keywords", func(t *testing.T) {
+		assert.Equal(t, "maxLength", TooLong("a", "query", 2, "abc").Keyword())
+		assert.Equal(t, "pattern", FailedPattern("a", "query", "\\d+", "a").Keyword())
+		assert.Equal(t, "enum", EnumFail("a", "query", "x", []interface{}{"y"}).Keyword())
+		assert.Empty(t, InvalidContentType("text/html", []string{"application/json"}).Keyword())
+
+		jazon, err := Required("a", "query", nil).MarshalJSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(jazon), `"keyword":"required"`)
+	})
+
+	t.Run("with CompositeError warnings", func(t *testing.T) {
+		warning := Required("a", "query", nil).AsWarning()
+		failure := TooLong("b", "query", 2, "abc")
+		err := CompositeValidationError(warning, CompositeValidationError(failure))
+
+		errs, warnings := err.SplitWarnings()
+		assert.Equal(t, []error{failure}, errs)
+		assert.Equal(t, []error{warning}, warnings)
+		assert.Equal(t, []error{warning}, err.Warnings())
+		assert.True(t, warning.IsWarning())
+		assert.False(t, failure.IsWarning())
+		assert.Equal(t, "warning", warning.Severity.String())
+		assert.Equal(t, "error", failure.Severity.String())
+
+		jazon, jerr := warning.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"severity":"warning"`)
+	})
+
+	t.Run("with FieldErrors", func(t *testing.T) {
+		err := CompositeValidationError(
+			Required("name", "body", nil),
+			CompositeValidationError(
+				TooShort("name", "body", 2, "a"),
+				EnumFail("kind", "body", "x", []interface{}{"a"}),
+			),
+			errors.New("unexpected"),
+		)
+		assert.Equal(t, map[string][]string{
+			"name": {"name in body is required", "name in body should be at least 2 chars long"},
+			"kind": {"kind in body should be one of [a]"},
+			"":     {"unexpected"},
+		}, err.FieldErrors())
+
+		assert.Empty(t, CompositeValidationError().FieldErrors())
+	})
+
+	t.Run("with AutoFlattenComposite", func(t *testing.T) {
+		oldAutoFlattenComposite := AutoFlattenComposite
+		defer func() { AutoFlattenComposite = oldAutoFlattenComposite }()
+		AutoFlattenComposite = true
+
+		var nilComposite *CompositeError
+		err := CompositeValidationError(
+			Required("a", "body", nil),
+			CompositeValidationError(Required("b", "body", nil), &CompositeError{Errors: []error{Required("c", "body", nil)}}),
+			nilComposite,
+		)
+		require.Len(t, err.Errors, 3)
+		assert.Equal(t, "validation failure list:\na in body is required\nb in body is required\nc in body is required", err.Error())
+	})
+
+	t.Run("with PrefixNames", func(t *testing.T) {
+		plain := errors.New("plain")
+		err := CompositeValidationError(
+			Required("zip", "body", nil),
+			CompositeValidationError(Required("city", "body", nil)),
+			plain,
+		).PrefixNames("address.")
+		assert.Equal(t, "address.zip", err.Errors[0].(*Validation).Name)
+		assert.Equal(t, "address.city in body is required", err.Errors[1].(*CompositeError).Errors[0].Error())
+		assert.Equal(t, plain, err.Errors[2])
+
+		var nilComposite *CompositeError
+		assert.Nil(t, nilComposite.PrefixNames("address"))
+
+		// nameless validations are left untouched
+		err = CompositeValidationError(
+			MutuallyExclusive("query", []string{"a", "b"}),
+			CompositeValidationError(InvalidTypeName("x")),
+		).PrefixNames("address")
+		assert.Equal(t, "only one of [a b] may be provided in query", err.Errors[0].Error())
+		assert.Empty(t, err.Errors[0].(*Validation).Name)
+		assert.Equal(t, "x is an invalid type name", err.Errors[1].(*CompositeError).Errors[0].Error())
+	})
+
+	t.Run("with Summary", func(t *testing.T) {
+		err := CompositeValidationError(
+			Required("a", "body", nil),
+			CompositeValidationError(InvalidType("b", "body", "integer", "x"), Required("c", "body", nil)),
+			errors.New("plain"),
+		)
+		assert.Equal(t, "422: 4 validation errors (required, type)", err.Summary())
+		assert.Equal(t, "422: 1 validation error (required)", ValidationError(Required("a", "body", nil)).Summary())
+		assert.Equal(t, "422: 0 validation errors", CompositeValidationError().Summary())
+	})
+
+	t.Run("with CompositeValidationErrorWithDetail", func(t *testing.T) {
+		err := CompositeValidationErrorWithDetail("2 fields are invalid", Required("a", "body", nil), Required("b", "body", nil))
+		assert.EqualValues(t, CompositeErrorCode, err.Code())
+		assert.Equal(t, "2 fields are invalid", err.Detail)
+		require.Len(t, err.Errors, 2)
+
+		jazon, jerr := err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"detail":"2 fields are invalid"`)
+		assert.Contains(t, string(jazon), `"message":"validation failure list"`)
+
+		jazon, jerr = err.MarshalJSONVerbose(false)
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"detail":"2 fields are invalid"`)
+
+		jazon, jerr = CompositeValidationError(Required("a", "body", nil)).MarshalJSON()
+		require.NoError(t, jerr)
+		assert.NotContains(t, string(jazon), `"detail"`)
+		// the detail never overrides the message
+		oldMessageFieldName := MessageFieldName
+		defer func() { MessageFieldName = oldMessageFieldName }()
+		MessageFieldName = "detail"
+
+		jazon, jerr = err.MarshalJSON()
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"detail":"validation failure list"`)
+		assert.NotContains(t, string(jazon), "2 fields are invalid")
+
+		jazon, jerr = err.MarshalJSONVerbose(false)
+		require.NoError(t, jerr)
+		assert.Contains(t, string(jazon), `"detail":"validation failure list"`)
+	})
+
+	t.Run("with ValidationError", func(t *testing.T) {
+		assert.Nil(t, ValidationError(nil))
+
+		err := ValidationError(Required("x", "body", nil))
+		require.Error(t, err)
+		assert.EqualValues(t, CompositeErrorCode, err.Code())
+		require.Len(t, err.Errors, 1)
+		assert.True(t, HasCode(err.First(), RequiredFailCode))
+	})
+
+	t.Run("with ToURLValues", func(t *testing.T) {
+		err := CompositeValidationError(
+			Required("email", "body", nil),
+			CompositeValidationError(InvalidType("email", "body", "email", "a&b")),
+		)
+		values := err.ToURLValues()
+		assert.Equal(t, []string{"email in body is required", `email in body must be of type email: "a&b"`}, values["email"])
+		assert.Equal(t,
+			"email=email+in+body+is+required&email=email+in+body+must+be+of+type+email%3A+%22a%26b%22",
+			values.Encode(),
+		)
+	})
+
+	t.Run("with Unwrap", func(t *testing.T) {
+		var nilComposite *CompositeError
+		assert.Nil(t, nilComposite.Unwrap())
+
+		child := Required("a", "body", nil)
+		var multi interface{ Unwrap() []error } = CompositeValidationError(child)
+		assert.Equal(t, []error{child}, multi.Unwrap())
+	})
+
+	t.Run("with ErrorOrNil", func(t *testing.T) {
+		require.NoError(t, CompositeValidationError().ErrorOrNil())
+		require.NoError(t, CompositeValidationError(CompositeValidationError()).ErrorOrNil())
+
+		var nilComposite *CompositeError
+		require.NoError(t, nilComposite.ErrorOrNil())
+
+		err := CompositeValidationError(errors.New("first error"))
+		assert.Equal(t, err, err.ErrorOrNil())
+	})
+
+	t.Run("with ToFieldObject", func(t *testing.T) {
+		err := CompositeValidationError(
+			Required("email", "body", nil),
+			CompositeValidationError(FailedPattern("email", "body", ".+@.+", "x")),
+			errors.New("unexpected"),
+		)
+		assert.Equal(t, map[string]interface{}{
+			"fields": map[string]interface{}{
+				"email": map[string]interface{}{
+					"messages": []string{"email in body is required", "email in body should match '.+@.+'"},
+				},
+			},
+		}, err.ToFieldObject())
+
+		assert.Equal(t, map[string]interface{}{"fields": map[string]interface{}{}}, CompositeValidationError().ToFieldObject())
+	})
+
+	t.Run("with HasCode and IsTypeError", func(t *testing.T) {
+		err := CompositeValidationError(
+			Required("a", "query", nil),
+			CompositeValidationError(InvalidType("b", "query", "integer", "x")),
+		)
+		assert.True(t, IsTypeError(err))
+		assert.True(t, HasCode(err, RequiredFailCode))
+		assert.True(t, HasCode(err, CompositeErrorCode))
+		assert.False(t, HasCode(err, PatternFailCode))
+		assert.True(t, IsTypeError(fmt.Errorf("wrapped: %w", InvalidTypeName("x"))))
+
+		assert.False(t, IsTypeError(nil))
+		assert.False(t, IsTypeError(Required("a", "query", nil)))
+
+		var nilComposite *CompositeError
+		assert.False(t, IsTypeError(nilComposite))
+
+		var nilValidation *Validation
+		assert.False(t, HasCode(nilValidation, RequiredFailCode))
+		assert.False(t, HasCode(CompositeValidationError(nilValidation), RequiredFailCode))
+		assert.False(t, HasCode(fmt.Errorf("wrapped: %w", nilValidation), RequiredFailCode))
+	})
+
+	t.Run("with MergeComposite", func(t *testing.T) {
+		testErr1 := errors.New("first error")
+		testErr2 := errors.New("second error")
+		testErr3 := errors.New("third error")
+		err := MergeComposite(
+			CompositeValidationError(testErr1),
+			nil,
+			CompositeValidationError(),
+			CompositeValidationError(testErr2, testErr3),
+		)
+		require.Error(t, err)
+		assert.EqualValues(t, CompositeErrorCode, err.Code())
+		assert.Equal(t, []error{testErr1, testErr2, testErr3}, err.Errors)
+
+		assert.Empty(t, MergeComposite().Errors)
+	})
+
+	t.Run("with SortedEqual", func(t *testing.T) {
+		a, b := Required("a", "body", nil), Required("b", "body", nil)
+
+		assert.True(t, SortedEqual(nil, nil))
+		assert.False(t, SortedEqual(CompositeValidationError(), nil))
+		assert.True(t, SortedEqual(CompositeValidationError(a, b), CompositeValidationError(b, a)))
+		assert.True(t, SortedEqual(CompositeValidationError(a, CompositeValidationError(b)), CompositeValidationError(b, a)))
+		assert.True(t, SortedEqual(CompositeValidationError(a, a, b), CompositeValidationError(a, b, a)))
+		assert.False(t, SortedEqual(CompositeValidationError(a, a, b), CompositeValidationError(a, b, b)))
+		assert.False(t, SortedEqual(CompositeValidationError(a), CompositeValidationError(a, b)))
+	})
+
+	t.Run("with CompositeError First/Last", func(t *testing.T) {
+		testErr1 := errors.New("first error")
+		testErr2 := errors.New("second error")
+		err := CompositeValidationError(
+			CompositeValidationError(),
+			testErr1,
+			CompositeValidationError(testErr2, CompositeValidationError()),
+		)
+		assert.Equal(t, testErr1, err.First())
+		assert.Equal(t, testErr2, err.Last())
+
+		empty := CompositeValidationError(CompositeValidationError())
+		require.NoError(t, empty.First())
+		require.NoError(t, empty.Last())
+
+		var nilComposite *CompositeError
+		require.NoError(t, nilComposite.First())
+		require.NoError(t, nilComposite.Last())
+	})
+
 	t.Run("should set validation name in CompositeValidation error", func(t *testing.T) {
 		err := CompositeValidationError(
 			InvalidContentType("text/html", []string{"application/json"}),
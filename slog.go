@@ -0,0 +1,53 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package errors
+
+import "log/slog"
+
+// LogValue implements the slog.LogValuer interface
+func (a apiError) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("code", int64(a.code)),
+		slog.String("message", sanitizeMessage(a.message)),
+	)
+}
+
+// LogValue implements the slog.LogValuer interface
+func (e Validation) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int64("code", int64(e.code)),
+		slog.String("message", sanitizeMessage(e.message)),
+		slog.String("name", e.Name),
+		slog.String("in", e.In),
+	)
+}
+
+// LogValue implements the slog.LogValuer interface
+func (c CompositeError) LogValue() slog.Value {
+	codes := make([]int32, 0, len(c.Errors))
+	for _, e := range c.Errors {
+		if ae, ok := e.(Error); ok {
+			codes = append(codes, ae.Code())
+		}
+	}
+	return slog.GroupValue(
+		slog.Int64("code", int64(c.code)),
+		slog.String("message", sanitizeMessage(c.message)),
+		slog.Int("count", len(c.Errors)),
+		slog.Any("codes", codes),
+	)
+}
@@ -0,0 +1,59 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.21
+
+package errors
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	logger.Error("request failed", "err", NotFound(""))
+	assert.JSONEq(t, `{"level":"ERROR","msg":"request failed","err":{"code":404,"message":"Not found"}}`, buf.String())
+
+	buf.Reset()
+	logger.Error("request failed", "err", Required("name", "query", nil))
+	assert.JSONEq(t,
+		`{"level":"ERROR","msg":"request failed","err":{"code":602,"message":"name in query is required","name":"name","in":"query"}}`,
+		buf.String(),
+	)
+
+	buf.Reset()
+	logger.Error("request failed", "err", CompositeValidationError(
+		Required("name", "query", nil),
+		errors.New("some error"),
+		InvalidTypeName("x"),
+	))
+	assert.JSONEq(t,
+		`{"level":"ERROR","msg":"request failed","err":{"code":422,"message":"validation failure list","count":3,"codes":[602,601]}}`,
+		buf.String(),
+	)
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+)
+
+// StreamCompositeThreshold is the number of errors from which ServeError streams a composite served in full,
+// e.g. with UnprocessableEntity, instead of buffering its whole JSON body.
+//
+// Streamed responses have no Content-Length. Streaming only applies to plain JSON responses: it is skipped when
+// another renderer is negotiated, when JSONRenderer is set or when the request info, a trace ID or a JSONP callback
+// should be added to the body. When 0, composites are never streamed.
+var StreamCompositeThreshold int
+
+// canStream tells if a composite should be streamed rather than buffered
+func canStream(r *http.Request, c *CompositeError) bool {
+	if StreamCompositeThreshold <= 0 || c == nil || !c.keepCode || len(c.Errors) < StreamCompositeThreshold {
+		return false
+	}
+	if JSONRenderer != nil || IncludeRequestInfo || TraceIDFromRequest != nil {
+		return false
+	}
+	if r == nil {
+		return true
+	}
+	if r.Method == http.MethodHead || (AllowJSONP && jsonpCallback(r) != "") {
+		return false
+	}
+	_, negotiated := negotiateRenderer(r)
+	return !negotiated
+}
+
+// streamComposite writes the JSON body of a composite one error at a time.
+//
+// The fields are written in the same order as the buffered body, so that both bodies are identical.
+func streamComposite(rw http.ResponseWriter, r *http.Request, c *CompositeError) (int, error) {
+	if !SkipContentTypeHeader {
+		rw.Header().Set("Content-Type", "application/json")
+	}
+	addVary(rw.Header(), r)
+	addDocLink(rw.Header(), c.Code())
+	rw.WriteHeader(c.HTTPStatus())
+
+	cw := &countingWriter{w: rw}
+	bw := bufio.NewWriter(cw)
+	keys := []string{CodeFieldName, MessageFieldName, "errors"}
+	if c.hasDetail() {
+		keys = append(keys, "detail")
+	}
+	if IncludeRetryable {
+		keys = append(keys, "retryable")
+	}
+	keys = uniqueSorted(keys)
+
+	_ = bw.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			_ = bw.WriteByte(',')
+		}
+		if err := writeJSON(bw, key); err != nil {
+			return cw.n, err
+		}
+		_ = bw.WriteByte(':')
+		var err error
+		// colliding field names resolve like in MarshalJSON, where the retryable flag wins over the errors,
+		// and the errors over the message and the code
+		switch {
+		case key == "retryable" && IncludeRetryable:
+			err = writeJSON(bw, retryable(c))
+		case key == "errors":
+			err = writeErrors(bw, c.Errors)
+		case key == MessageFieldName:
+			err = writeJSON(bw, jsonMessage(c.message))
+		case key == CodeFieldName:
+			err = writeJSON(bw, jsonCode(c.code))
+		default:
+			err = writeJSON(bw, c.Detail)
+		}
+		if err != nil {
+			return cw.n, err
+		}
+	}
+	_ = bw.WriteByte('}')
+	if AppendNewline {
+		_ = bw.WriteByte('\n')
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// uniqueSorted sorts keys and removes duplicates, so that no field is written twice
+func uniqueSorted(keys []string) []string {
+	sort.Strings(keys)
+	res := keys[:0]
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+		res = append(res, key)
+	}
+	return res
+}
+
+func writeErrors(w *bufio.Writer, errs []error) error {
+	_ = w.WriteByte('[')
+	for i, e := range errs {
+		if i > 0 {
+			_ = w.WriteByte(',')
+		}
+		if err := writeJSON(w, e); err != nil {
+			return err
+		}
+	}
+	return w.WriteByte(']')
+}
+
+func writeJSON(w *bufio.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// countingWriter counts the bytes written to the underlying writer
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
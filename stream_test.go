@@ -0,0 +1,103 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamComposite(t *testing.T) {
+	oldStreamCompositeThreshold := StreamCompositeThreshold
+	defer func() { StreamCompositeThreshold = oldStreamCompositeThreshold }()
+	StreamCompositeThreshold = 3
+
+	errs := make([]error, 0, 5)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		errs = append(errs, Required(name, "body", nil))
+	}
+	errs = append(errs, errors.New("plain"))
+	err := UnprocessableEntity(errs...)
+	err.(*CompositeError).Detail = "4 fields are missing"
+
+	status, contentType, body := RenderError(err, nil)
+
+	recorder := httptest.NewRecorder()
+	n, werr := ServeErrorN(recorder, nil, err)
+	require.NoError(t, werr)
+	assert.Equal(t, status, recorder.Code)
+	assert.Equal(t, contentType, recorder.Header().Get("Content-Type"))
+	assert.Empty(t, recorder.Header().Get("Content-Length"))
+	assert.Equal(t, string(body), recorder.Body.String())
+	assert.Contains(t, recorder.Body.String(), `"detail":"4 fields are missing"`)
+	assert.Equal(t, len(body), n)
+
+	// small composites are buffered
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, nil, UnprocessableEntity(errs[:2]...))
+	assert.NotEmpty(t, recorder.Header().Get("Content-Length"))
+
+	// other renderers are buffered
+	oldNegotiateContentType := NegotiateContentType
+	defer func() { NegotiateContentType = oldNegotiateContentType }()
+	NegotiateContentType = true
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+	recorder = httptest.NewRecorder()
+	ServeError(recorder, r, err)
+	assert.Equal(t, "text/plain; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.NotEmpty(t, recorder.Header().Get("Content-Length"))
+}
+
+func TestStreamCompositeFieldNames(t *testing.T) {
+	oldStreamCompositeThreshold, oldMessageFieldName := StreamCompositeThreshold, MessageFieldName
+	defer func() { StreamCompositeThreshold, MessageFieldName = oldStreamCompositeThreshold, oldMessageFieldName }()
+	StreamCompositeThreshold = 1
+	MessageFieldName = "detail"
+
+	err := UnprocessableEntity(Required("a", "body", nil))
+	err.(*CompositeError).Detail = "1 field is missing"
+
+	_, _, body := RenderError(err, nil)
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Empty(t, recorder.Header().Get("Content-Length"))
+	assert.Equal(t, string(body), recorder.Body.String())
+	assert.True(t, strings.HasPrefix(recorder.Body.String(), `{"code":422,"detail":"validation failure list","errors":[`))
+	assert.NotContains(t, recorder.Body.String(), "1 field is missing")
+}
+
+func TestStreamCompositeRetryable(t *testing.T) {
+	oldStreamCompositeThreshold, oldIncludeRetryable := StreamCompositeThreshold, IncludeRetryable
+	defer func() { StreamCompositeThreshold, IncludeRetryable = oldStreamCompositeThreshold, oldIncludeRetryable }()
+	StreamCompositeThreshold = 1
+	IncludeRetryable = true
+
+	err := UnprocessableEntity(Required("a", "body", nil))
+
+	_, _, body := RenderError(err, nil)
+	recorder := httptest.NewRecorder()
+	ServeError(recorder, nil, err)
+	assert.Empty(t, recorder.Header().Get("Content-Length"))
+	assert.Equal(t, string(body), recorder.Body.String())
+	assert.True(t, strings.HasSuffix(recorder.Body.String(), `"retryable":false}`))
+}
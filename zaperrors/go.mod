@@ -0,0 +1,18 @@
+module github.com/go-openapi/errors/zaperrors
+
+require (
+	github.com/go-openapi/errors v0.22.0
+	github.com/stretchr/testify v1.10.0
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/go-openapi/errors => ../
+
+go 1.20
@@ -0,0 +1,88 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package zaperrors provides structured logging of go-openapi errors with go.uber.org/zap.
+
+It lives in its own module, so the errors package does not depend on zap.
+*/
+package zaperrors
+
+import (
+	"reflect"
+
+	"github.com/go-openapi/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Object returns a zap object marshaler for an error.
+//
+// API errors log their code and message, validation errors add their name and location,
+// and composite errors log their children as an array.
+func Object(err error) zapcore.ObjectMarshaler {
+	return errorMarshaler{err: err}
+}
+
+// Error builds a zap field named "error" for an error
+func Error(err error) zap.Field {
+	return zap.Object("error", Object(err))
+}
+
+type errorMarshaler struct {
+	err error
+}
+
+// MarshalLogObject implements the zapcore.ObjectMarshaler interface
+func (m errorMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	switch e := m.err.(type) {
+	case nil:
+		return nil
+	case *errors.CompositeError:
+		if e == nil {
+			return nil
+		}
+		enc.AddInt32("code", e.Code())
+		return enc.AddArray("errors", errorsMarshaler(e.Errors))
+	case *errors.Validation:
+		if e == nil {
+			return nil
+		}
+		enc.AddInt32("code", e.Code())
+		enc.AddString("message", e.Error())
+		enc.AddString("name", e.Name)
+		enc.AddString("in", e.In)
+	case errors.Error:
+		if value := reflect.ValueOf(e); value.Kind() == reflect.Ptr && value.IsNil() {
+			return nil
+		}
+		enc.AddInt32("code", e.Code())
+		enc.AddString("message", e.Error())
+	default:
+		enc.AddString("message", e.Error())
+	}
+	return nil
+}
+
+type errorsMarshaler []error
+
+// MarshalLogArray implements the zapcore.ArrayMarshaler interface
+func (m errorsMarshaler) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range m {
+		if err := enc.AppendObject(Object(err)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2015 go-swagger maintainers
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zaperrors
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/go-openapi/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestObject(t *testing.T) {
+	enc := zapcore.NewMapObjectEncoder()
+	require.NoError(t, Object(errors.NotFound("")).MarshalLogObject(enc))
+	assert.Equal(t, map[string]interface{}{"code": int32(404), "message": "Not found"}, enc.Fields)
+
+	enc = zapcore.NewMapObjectEncoder()
+	require.NoError(t, Object(errors.Required("name", "query", nil)).MarshalLogObject(enc))
+	assert.Equal(t, map[string]interface{}{
+		"code":    int32(errors.RequiredFailCode),
+		"message": "name in query is required",
+		"name":    "name",
+		"in":      "query",
+	}, enc.Fields)
+
+	enc = zapcore.NewMapObjectEncoder()
+	require.NoError(t, Object(errors.CompositeValidationError(
+		errors.NotFound(""),
+		stderrors.New("some error"),
+	)).MarshalLogObject(enc))
+	assert.Equal(t, map[string]interface{}{
+		"code": int32(errors.CompositeErrorCode),
+		"errors": []interface{}{
+			map[string]interface{}{"code": int32(404), "message": "Not found"},
+			map[string]interface{}{"message": "some error"},
+		},
+	}, enc.Fields)
+
+	enc = zapcore.NewMapObjectEncoder()
+	field := Error(errors.NotFound(""))
+	field.AddTo(enc)
+	assert.Equal(t, map[string]interface{}{"code": int32(404), "message": "Not found"}, enc.Fields["error"])
+}
+
+func TestObjectTypedNil(t *testing.T) {
+	for _, err := range []errors.Error{
+		(*errors.ParseError)(nil),
+		(*errors.MethodNotAllowedError)(nil),
+		(*errors.Validation)(nil),
+		(*errors.CompositeError)(nil),
+	} {
+		enc := zapcore.NewMapObjectEncoder()
+		require.NotPanics(t, func() {
+			require.NoError(t, Object(err).MarshalLogObject(enc))
+		})
+		assert.Empty(t, enc.Fields)
+	}
+}